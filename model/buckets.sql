@@ -12,7 +12,13 @@ CREATE TABLE IF NOT EXISTS public.buckets (
     title varchar(150) NOT NULL CHECK (title <> ''),
     description varchar(2000),
     status bucket_status NOT NULL DEFAULT 'ACTIVE',
-    edited boolean NOT NULL DEFAULT false
+    edited boolean NOT NULL DEFAULT false,
+    deleted_at timestamptz
 );
 
-CREATE INDEX IF NOT EXISTS buckets_sqid_index ON buckets (sqid);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS buckets_sqid_index ON buckets (sqid);
+
+-- Full-text search index; the expression must match the search document in src/server/model/search.go
+CREATE INDEX IF NOT EXISTS buckets_search_index ON buckets USING GIN (
+	(setweight(to_tsvector('english', title), 'A') || setweight(to_tsvector('english', coalesce(description, '')), 'B'))
+);
\ No newline at end of file
@@ -22,5 +22,15 @@ VALUES ('server_name', 'TODO-APP-SERVER'),
 	('comment_max_len', '8000'),
 	('tag_title_max_len', '30'),
 	('tag_desc_max_len', '2000'),
+	('tag_max_depth', '4'),
 	('bucket_title_max_len', '150'),
-	('bucket_desc_max_len', '2000');
\ No newline at end of file
+	('bucket_desc_max_len', '2000'),
+	('saved_view_name_max_len', '150'),
+	('sprint_title_template', 'Sprint {number}'),
+	(
+		'trash_retention_seconds',
+		EXTRACT(
+			EPOCH
+			FROM '30 days'::interval
+		)
+	);
\ No newline at end of file
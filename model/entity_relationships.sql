@@ -1,7 +1,11 @@
 -- Stores relationships between tasks and stories
 
 CREATE TYPE relationship AS ENUM (
-	'CONTINUED_BY' -- story_b is story_a continued in the new sprint
+	'CONTINUED_BY', -- story_b is story_a continued in the new sprint
+	'BLOCKS', -- story_b cannot be finished before story_a
+	'RELATES_TO',
+	'DUPLICATES', -- story_a duplicates story_b
+	'PARENT_OF' -- story_b is part of story_a
 );
 
 CREATE TABLE IF NOT EXISTS public.story_relationships (
@@ -14,3 +18,24 @@ CREATE TABLE IF NOT EXISTS public.story_relationships (
     CONSTRAINT fk_story_id_b FOREIGN KEY(story_id_b) REFERENCES stories(id) ON DELETE CASCADE,
     UNIQUE(story_id_a, story_id_b, relation)
 );
+
+CREATE TYPE task_relationship AS ENUM (
+	'BLOCKS', -- task_b cannot be started before task_a is finished
+	'RELATES_TO',
+	'DUPLICATES' -- task_a duplicates task_b
+);
+
+-- BLOCKS relationships may not form a cycle
+CREATE TABLE IF NOT EXISTS public.task_relationships (
+    id INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    task_id_a uuid NOT NULL,
+    task_id_b uuid NOT NULL,
+    relation task_relationship NOT NULL,
+    CONSTRAINT fk_task_id_a FOREIGN KEY(task_id_a) REFERENCES tasks(id) ON DELETE CASCADE,
+    CONSTRAINT fk_task_id_b FOREIGN KEY(task_id_b) REFERENCES tasks(id) ON DELETE CASCADE,
+    CONSTRAINT task_relationship_not_self CHECK (task_id_a <> task_id_b),
+    UNIQUE(task_id_a, task_id_b, relation)
+);
+
+CREATE INDEX IF NOT EXISTS task_relationships_task_id_b_index ON task_relationships (task_id_b);
@@ -31,7 +31,48 @@ CREATE TYPE event_action AS ENUM (
 	'CreateBucket',
 	'GetBucketTagAssignments',
 	'CreateBucketTagAssignment',
-	'DestroyBucketTagAssignmentByID'
+	'DestroyBucketTagAssignmentByID',
+	'GetCacheStats',
+	'DestroyTask',
+	'DestroyComment',
+	'DestroyStory',
+	'DestroySprint',
+	'DestroyTag',
+	'DestroyBucket',
+	'GetTrash',
+	'RestoreEntity',
+	'PutSprint',
+	'PutTag',
+	'PutBucket',
+	'GetBucketByID',
+	'PatchTask',
+	'PatchStory',
+	'GetEntityHistory',
+	'GetSprintFlowMetrics',
+	'GetSprintReport',
+	'RolloverSprint',
+	'GetCurrentSprint',
+	'GetSprintCalendarIssues',
+	'GetStoryGraph',
+	'GetTaskRelationships',
+	'CreateTaskRelationship',
+	'DestroyTaskRelationship',
+	'GetTaskTagAssignments',
+	'GetTaskTags',
+	'CreateTaskTagAssignment',
+	'DestroyTaskTagAssignmentByID',
+	'Search',
+	'GetSavedViews',
+	'CreateSavedView',
+	'PutSavedView',
+	'DestroySavedView',
+	'GetViewResults',
+	'BulkUpdate',
+	'GetChecklistItems',
+	'CreateChecklistItem',
+	'PutChecklistItem',
+	'ReorderChecklistItems',
+	'DestroyChecklistItem'
 );
 
 CREATE TYPE event_action_type AS ENUM (
@@ -0,0 +1,3 @@
+-- Add new event_action value for bulk updates of tasks and stories.
+-- A bulk update is recorded as one event; each entity it changes gets its own history.
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'BulkUpdate';
@@ -0,0 +1,2 @@
+-- Add new event_action value for the cache stats endpoint
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'GetCacheStats';
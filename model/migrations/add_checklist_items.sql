@@ -0,0 +1,23 @@
+-- Ordered checklist items on a task.  position is unique within a task;
+-- the constraint is deferrable so a reorder can swap positions in one statement
+CREATE TABLE IF NOT EXISTS public.checklist_items
+(
+		id INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+		task_id uuid NOT NULL,
+		created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at timestamp without time zone,
+		text character varying(8000) NOT NULL,
+		done boolean NOT NULL DEFAULT false,
+		position integer NOT NULL,
+		edited boolean NOT NULL DEFAULT false,
+		CONSTRAINT fk_task_id FOREIGN KEY(task_id) REFERENCES tasks(id),
+		CONSTRAINT text_not_empty CHECK (text <> ''),
+		CONSTRAINT checklist_items_position_unique UNIQUE (task_id, position) DEFERRABLE
+);
+
+-- Add new event_action values for checklist items
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'GetChecklistItems';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'CreateChecklistItem';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'PutChecklistItem';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'ReorderChecklistItems';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'DestroyChecklistItem';
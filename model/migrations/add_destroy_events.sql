@@ -0,0 +1,7 @@
+-- Add new event_action values for entity deletion
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'DestroyTask';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'DestroyComment';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'DestroyStory';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'DestroySprint';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'DestroyTag';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'DestroyBucket';
@@ -0,0 +1,18 @@
+-- One row per revision of a task, story or comment.
+-- changes maps each field that changed to its old and new value, e.g.
+-- {"status": {"old": "DOING", "new": "DONE"}}
+-- entity_id is text since comments have integer ids
+CREATE TABLE IF NOT EXISTS public.entity_history
+(
+		id INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+		created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		entity_kind character varying(20) NOT NULL,
+		entity_id character varying(36) NOT NULL,
+		action character varying(20) NOT NULL,
+		changes jsonb NOT NULL DEFAULT '{}'::jsonb
+);
+
+CREATE INDEX IF NOT EXISTS entity_history_entity_index ON entity_history (entity_kind, entity_id);
+
+-- Add new event_action value for reading the history
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'GetEntityHistory';
@@ -0,0 +1,2 @@
+-- Add new event_action value for fetching a bucket with its tasks
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'GetBucketByID';
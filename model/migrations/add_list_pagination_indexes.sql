@@ -0,0 +1,8 @@
+-- Support the default sort of get_tasks and its story and bucket filters
+CREATE INDEX IF NOT EXISTS tasks_created_at_id_index ON tasks (created_at, id);
+CREATE INDEX IF NOT EXISTS tasks_story_id_index ON tasks (story_id);
+CREATE INDEX IF NOT EXISTS tasks_bucket_id_index ON tasks (bucket_id);
+
+-- Support the default sort of get_stories and its sprint filter
+CREATE INDEX IF NOT EXISTS stories_created_at_id_index ON stories (created_at, id);
+CREATE INDEX IF NOT EXISTS stories_sprint_id_index ON stories (sprint_id);
@@ -0,0 +1,3 @@
+-- Add new event_action values for partial task and story updates
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'PatchTask';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'PatchStory';
@@ -0,0 +1,4 @@
+-- Add new event_action values for sprint, tag and bucket updates
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'PutSprint';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'PutTag';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'PutBucket';
@@ -0,0 +1,2 @@
+-- Add new event_action value for rolling unfinished stories over into the next sprint
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'RolloverSprint';
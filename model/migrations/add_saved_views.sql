@@ -0,0 +1,27 @@
+-- Named filters over tasks or stories.
+-- filter holds the query parameters of get_tasks or get_stories as lists, e.g.
+-- {"status": ["DOING"], "sprint_id": ["current"]}
+-- where a sprint_id of "current" follows whichever sprint includes today
+CREATE TABLE IF NOT EXISTS public.saved_views
+(
+		id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+		created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at timestamp without time zone,
+		name character varying(150) UNIQUE NOT NULL,
+		entity_kind character varying(20) NOT NULL,
+		filter jsonb NOT NULL DEFAULT '{}'::jsonb,
+		edited boolean NOT NULL DEFAULT false,
+		CONSTRAINT name_not_empty CHECK (name <> ''),
+		CONSTRAINT saved_view_entity_kind CHECK (entity_kind IN ('task', 'story'))
+);
+
+INSERT INTO config (key, value)
+VALUES ('saved_view_name_max_len', '150')
+ON CONFLICT (key) DO NOTHING;
+
+-- Add new event_action values for saved views
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'GetSavedViews';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'CreateSavedView';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'PutSavedView';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'DestroySavedView';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'GetViewResults';
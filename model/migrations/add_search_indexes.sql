@@ -0,0 +1,19 @@
+-- Full-text search indexes; the expressions must match the search documents in src/server/model/search.go
+CREATE INDEX IF NOT EXISTS tasks_search_index ON tasks USING GIN (
+	(setweight(to_tsvector('english', title), 'A') || setweight(to_tsvector('english', coalesce(description, '')), 'B'))
+);
+
+CREATE INDEX IF NOT EXISTS stories_search_index ON stories USING GIN (
+	(setweight(to_tsvector('english', title), 'A') || setweight(to_tsvector('english', coalesce(description, '')), 'B'))
+);
+
+CREATE INDEX IF NOT EXISTS buckets_search_index ON buckets USING GIN (
+	(setweight(to_tsvector('english', title), 'A') || setweight(to_tsvector('english', coalesce(description, '')), 'B'))
+);
+
+CREATE INDEX IF NOT EXISTS comments_search_index ON comments USING GIN (
+	(to_tsvector('english', text))
+);
+
+-- Add new event_action value for search
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'Search';
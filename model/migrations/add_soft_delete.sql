@@ -0,0 +1,19 @@
+-- Destroyed tasks, stories, buckets and comments are moved to the trash
+-- and purged once they have been there longer than trash_retention_seconds
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE stories ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE buckets ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+INSERT INTO config (key, value)
+VALUES (
+	'trash_retention_seconds',
+	EXTRACT(
+		EPOCH
+		FROM '30 days'::interval
+	)
+) ON CONFLICT (key) DO NOTHING;
+
+-- Add new event_action values for the trash
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'GetTrash';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'RestoreEntity';
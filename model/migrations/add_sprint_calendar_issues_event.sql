@@ -0,0 +1,2 @@
+-- Add new event_action value for listing sprint overlaps and gaps
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'GetSprintCalendarIssues';
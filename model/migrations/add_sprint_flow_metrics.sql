@@ -0,0 +1,6 @@
+-- status changes are what the sprint flow metrics are computed from
+CREATE INDEX IF NOT EXISTS entity_history_status_index ON entity_history (entity_kind, entity_id, id)
+WHERE changes ? 'status';
+
+-- Add new event_action value for the sprint flow metrics
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'GetSprintFlowMetrics';
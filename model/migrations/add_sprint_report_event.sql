@@ -0,0 +1,2 @@
+-- Add new event_action value for the sprint burndown and velocity report
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'GetSprintReport';
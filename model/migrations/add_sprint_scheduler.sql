@@ -0,0 +1,8 @@
+-- Sprints are created automatically; their titles follow this template.
+-- {number}, {start_date} and {end_date} are filled in for each new sprint.
+INSERT INTO config (key, value)
+VALUES ('sprint_title_template', 'Sprint {number}')
+ON CONFLICT (key) DO NOTHING;
+
+-- Add new event_action value for resolving the current sprint
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'GetCurrentSprint';
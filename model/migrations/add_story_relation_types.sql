@@ -0,0 +1,9 @@
+-- New kinds of story relationships.
+-- BLOCKS and PARENT_OF relationships may not form a cycle.
+ALTER TYPE relationship ADD VALUE IF NOT EXISTS 'BLOCKS';
+ALTER TYPE relationship ADD VALUE IF NOT EXISTS 'RELATES_TO';
+ALTER TYPE relationship ADD VALUE IF NOT EXISTS 'DUPLICATES';
+ALTER TYPE relationship ADD VALUE IF NOT EXISTS 'PARENT_OF';
+
+-- Add new event_action value for the story dependency graph
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'GetStoryGraph';
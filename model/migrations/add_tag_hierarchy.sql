@@ -0,0 +1,12 @@
+-- Tags may have a parent tag
+ALTER TABLE tags ADD COLUMN IF NOT EXISTS parent_id uuid;
+ALTER TABLE tags ADD CONSTRAINT fk_parent_id FOREIGN KEY(parent_id) REFERENCES tags(id);
+ALTER TABLE tags ADD CONSTRAINT tag_not_own_parent CHECK (parent_id <> id);
+CREATE INDEX IF NOT EXISTS idx_tags_parent_id ON tags(parent_id);
+
+-- is_parent was never set; it now means the tag has children
+UPDATE tags SET is_parent = false;
+
+INSERT INTO config (key, value)
+VALUES ('tag_max_depth', '4')
+ON CONFLICT (key) DO NOTHING;
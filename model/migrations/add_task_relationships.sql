@@ -0,0 +1,26 @@
+-- Relationships between tasks, like story_relationships
+CREATE TYPE task_relationship AS ENUM (
+	'BLOCKS', -- task_b cannot be started before task_a is finished
+	'RELATES_TO',
+	'DUPLICATES' -- task_a duplicates task_b
+);
+
+-- BLOCKS relationships may not form a cycle
+CREATE TABLE IF NOT EXISTS public.task_relationships (
+    id INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    task_id_a uuid NOT NULL,
+    task_id_b uuid NOT NULL,
+    relation task_relationship NOT NULL,
+    CONSTRAINT fk_task_id_a FOREIGN KEY(task_id_a) REFERENCES tasks(id) ON DELETE CASCADE,
+    CONSTRAINT fk_task_id_b FOREIGN KEY(task_id_b) REFERENCES tasks(id) ON DELETE CASCADE,
+    CONSTRAINT task_relationship_not_self CHECK (task_id_a <> task_id_b),
+    UNIQUE(task_id_a, task_id_b, relation)
+);
+
+CREATE INDEX IF NOT EXISTS task_relationships_task_id_b_index ON task_relationships (task_id_b);
+
+-- Add new event_action values for task relationships
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'GetTaskRelationships';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'CreateTaskRelationship';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'DestroyTaskRelationship';
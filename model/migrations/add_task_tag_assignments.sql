@@ -0,0 +1,18 @@
+-- Tags can be assigned to tasks as well as stories and buckets
+CREATE TABLE IF NOT EXISTS public.task_tag_assignments
+(
+		id INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+		created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		tag_id uuid NOT NULL,
+		task_id uuid NOT NULL,
+		CONSTRAINT fk_tag_id FOREIGN KEY(tag_id) REFERENCES tags(id),
+		CONSTRAINT fk_task_id FOREIGN KEY(task_id) REFERENCES tasks(id),
+		UNIQUE (tag_id, task_id)
+);
+CREATE INDEX IF NOT EXISTS idx_task_tag_assignments_task_id ON task_tag_assignments(task_id);
+
+-- Add new event_action values for task tag assignments
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'GetTaskTagAssignments';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'GetTaskTags';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'CreateTaskTagAssignment';
+ALTER TYPE event_action ADD VALUE IF NOT EXISTS 'DestroyTaskTagAssignmentByID';
@@ -0,0 +1,3 @@
+-- Sessions are now rehydrated from the database on startup and
+-- looked up by session_id on an in-memory miss
+CREATE INDEX CONCURRENTLY IF NOT EXISTS sessions_session_id_index ON sessions (session_id);
@@ -17,10 +17,16 @@ CREATE TABLE IF NOT EXISTS public.tags
 		updated_at timestamp without time zone,
 		title character varying(30) NOT NULL,
 		description character varying(2000),
+		-- tags nest at most tag_max_depth deep, without cycles
+		parent_id uuid,
+		-- maintained by the server: true if any tag has this one as its parent
 		is_parent boolean NOT NULL DEFAULT false,
 		edited boolean NOT NULL DEFAULT false,
-		CONSTRAINT title_not_empty CHECK (title <> '')
+		CONSTRAINT title_not_empty CHECK (title <> ''),
+		CONSTRAINT fk_parent_id FOREIGN KEY(parent_id) REFERENCES tags(id),
+		CONSTRAINT tag_not_own_parent CHECK (parent_id <> id)
 );
+CREATE INDEX IF NOT EXISTS idx_tags_parent_id ON tags(parent_id);
 
 -- TODO: rename to story_tag_assignments
 CREATE TABLE IF NOT EXISTS public.tag_assignments
@@ -45,6 +51,18 @@ CREATE TABLE IF NOT EXISTS public.bucket_tag_assignments
 		UNIQUE (tag_id, bucket_id)
 );
 
+CREATE TABLE IF NOT EXISTS public.task_tag_assignments
+(
+		id INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+		created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		tag_id uuid NOT NULL,
+		task_id uuid NOT NULL,
+		CONSTRAINT fk_tag_id FOREIGN KEY(tag_id) REFERENCES tags(id),
+		CONSTRAINT fk_task_id FOREIGN KEY(task_id) REFERENCES tasks(id),
+		UNIQUE (tag_id, task_id)
+);
+CREATE INDEX IF NOT EXISTS idx_task_tag_assignments_task_id ON task_tag_assignments(task_id);
+
 CREATE TABLE IF NOT EXISTS public.comments
 (
 		id INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
@@ -53,9 +71,32 @@ CREATE TABLE IF NOT EXISTS public.comments
 		updated_at timestamp without time zone,
 		text character varying(8000) NOT NULL,
 		edited boolean NOT NULL DEFAULT false,
+		deleted_at timestamptz,
 		CONSTRAINT fk_task_id FOREIGN KEY(task_id) REFERENCES tasks(id),
 		CONSTRAINT text_not_empty CHECK (text <> '')
 );
 -- Index on task_id to speed up joins and lookups by task,
 -- since PostgreSQL does not automatically index foreign key columns
 CREATE INDEX IF NOT EXISTS idx_comments_task_id ON comments(task_id);
+
+-- Full-text search index; the expression must match the search document in src/server/model/search.go
+CREATE INDEX IF NOT EXISTS comments_search_index ON comments USING GIN (
+	(to_tsvector('english', text))
+);
+
+-- Ordered checklist items on a task.  position is unique within a task;
+-- the constraint is deferrable so a reorder can swap positions in one statement
+CREATE TABLE IF NOT EXISTS public.checklist_items
+(
+		id INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+		task_id uuid NOT NULL,
+		created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at timestamp without time zone,
+		text character varying(8000) NOT NULL,
+		done boolean NOT NULL DEFAULT false,
+		position integer NOT NULL,
+		edited boolean NOT NULL DEFAULT false,
+		CONSTRAINT fk_task_id FOREIGN KEY(task_id) REFERENCES tasks(id),
+		CONSTRAINT text_not_empty CHECK (text <> ''),
+		CONSTRAINT checklist_items_position_unique UNIQUE (task_id, position) DEFERRABLE
+);
@@ -0,0 +1,16 @@
+-- Named filters over tasks or stories.
+-- filter holds the query parameters of get_tasks or get_stories as lists, e.g.
+-- {"status": ["DOING"], "sprint_id": ["current"]}
+-- where a sprint_id of "current" follows whichever sprint includes today
+CREATE TABLE IF NOT EXISTS public.saved_views
+(
+		id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+		created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at timestamp without time zone,
+		name character varying(150) UNIQUE NOT NULL,
+		entity_kind character varying(20) NOT NULL,
+		filter jsonb NOT NULL DEFAULT '{}'::jsonb,
+		edited boolean NOT NULL DEFAULT false,
+		CONSTRAINT name_not_empty CHECK (name <> ''),
+		CONSTRAINT saved_view_entity_kind CHECK (entity_kind IN ('task', 'story'))
+);
@@ -9,3 +9,6 @@ CREATE TABLE IF NOT EXISTS public.sessions
 		session_created_at timestamp without time zone NOT NULL,
 		session_last_accessed timestamp without time zone NOT NULL
 );
+
+-- sessions are looked up by session_id when the server's in-memory map misses
+CREATE INDEX IF NOT EXISTS sessions_session_id_index ON sessions (session_id);
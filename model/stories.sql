@@ -18,6 +18,7 @@ CREATE TABLE IF NOT EXISTS public.stories (
     status story_status DEFAULT 'BACKLOG'::story_status,
     sprint_id uuid,
     edited boolean NOT NULL DEFAULT false,
+    deleted_at timestamptz,
     CONSTRAINT fk_sprint_id FOREIGN KEY(sprint_id) REFERENCES sprints(id),
     CONSTRAINT title_not_empty CHECK (title <> ''),
     UNIQUE (title, sprint_id)
@@ -25,6 +26,15 @@ CREATE TABLE IF NOT EXISTS public.stories (
 
 CREATE INDEX stories_sqid_index ON stories (sqid);
 
+-- Support the default sort of get_stories and its sprint filter
+CREATE INDEX IF NOT EXISTS stories_created_at_id_index ON stories (created_at, id);
+CREATE INDEX IF NOT EXISTS stories_sprint_id_index ON stories (sprint_id);
+
+-- Full-text search index; the expression must match the search document in src/server/model/search.go
+CREATE INDEX IF NOT EXISTS stories_search_index ON stories USING GIN (
+	(setweight(to_tsvector('english', title), 'A') || setweight(to_tsvector('english', coalesce(description, '')), 'B'))
+);
+
 -- Prevent duplicate story creation within a short time window
 -- This constraint prevents double-click submissions by ensuring no two stories
 -- with the same title, description, and sprint_id are created within the same second
@@ -21,11 +21,22 @@ CREATE TABLE IF NOT EXISTS public.tasks (
     bucket_id uuid REFERENCES buckets(id),
     edited boolean NOT NULL DEFAULT false,
     bulk_task boolean NOT NULL DEFAULT false,
+    deleted_at timestamptz,
     CHECK (story_id IS NULL OR bucket_id IS NULL)
 );
 
 CREATE INDEX IF NOT EXISTS tasks_sqid_index ON tasks (sqid);
 
+-- Support the default sort of get_tasks and its story and bucket filters
+CREATE INDEX IF NOT EXISTS tasks_created_at_id_index ON tasks (created_at, id);
+CREATE INDEX IF NOT EXISTS tasks_story_id_index ON tasks (story_id);
+CREATE INDEX IF NOT EXISTS tasks_bucket_id_index ON tasks (bucket_id);
+
+-- Full-text search index; the expression must match the search document in src/server/model/search.go
+CREATE INDEX IF NOT EXISTS tasks_search_index ON tasks USING GIN (
+	(setweight(to_tsvector('english', title), 'A') || setweight(to_tsvector('english', coalesce(description, '')), 'B'))
+);
+
 -- Prevent duplicate task creation within a short time window
 -- This constraint prevents double-click submissions by ensuring no two tasks
 -- with the same title, description, and story_id are created within the same second
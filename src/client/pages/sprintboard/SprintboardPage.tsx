@@ -3,6 +3,7 @@ import ErrorBanner from "../../components/banners";
 import {
   checkSession,
   getConfig,
+  getCurrentSprint,
   getSprints,
   getStories,
   getStoryRelationships,
@@ -11,7 +12,7 @@ import {
   getTasks,
   getBuckets,
   getBucketTagAssignments,
-  updateTaskById,
+  patchTaskById,
 } from "../../ts/lib/api";
 import {
   type Bucket,
@@ -107,6 +108,14 @@ export default function SprintboardPage() {
     };
   }, []);
 
+  // default to viewing the current sprint
+  useEffect(() => {
+    if (selectedSprintId) return;
+    void getCurrentSprint().then((sprint) => {
+      if (sprint !== null) setSelectedSprintId(sprint.id);
+    });
+  }, []);
+
   // local storage side effects
   useEffect(() => {
     localStorage.setItem(
@@ -330,12 +339,15 @@ export default function SprintboardPage() {
         _task.id === task.id ? { ..._task, status } : _task,
       ),
     );
-    await updateTaskById(
-      task.id,
+    const savedTask = await patchTaskById(task.id, task.updated_at, {
       status,
-      task.title,
-      task.description,
-      task.story_id,
+    });
+    setTasks((tasks) =>
+      tasks.map((_task) =>
+        _task.id === task.id
+          ? { ..._task, updated_at: savedTask.updated_at }
+          : _task,
+      ),
     );
     broadcast({ type: "task-mutated", taskId: task.id });
   }
@@ -94,15 +94,20 @@ export default function StoryCard({
     }
     if (!diff) return;
 
-    await updateStoryById(
+    const savedStory = await updateStoryById(
       updatedStory.id,
       updatedStory.status,
       updatedStory.title,
       updatedStory.description,
       updatedStory.sprint_id,
+      story.updated_at,
     );
     setStories((stories) =>
-      stories.map((s) => (s.id === story.id ? updatedStory : s)),
+      stories.map((s) =>
+        s.id === story.id
+          ? { ...updatedStory, updated_at: savedStory.updated_at }
+          : s,
+      ),
     );
   }
 
@@ -375,16 +380,19 @@ export default function StoryCard({
                       ...story,
                       sprint_id: e.target.value,
                     };
-                    await updateStoryById(
+                    const savedStory = await updateStoryById(
                       updatedStory.id,
                       updatedStory.status,
                       updatedStory.title,
                       updatedStory.description,
                       updatedStory.sprint_id,
+                      story.updated_at,
                     );
                     setStories((stories) =>
                       stories.map((s) =>
-                        s.id === story.id ? updatedStory : s,
+                        s.id === story.id
+                          ? { ...updatedStory, updated_at: savedStory.updated_at }
+                          : s,
                       ),
                     );
                   })();
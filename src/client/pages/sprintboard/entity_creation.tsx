@@ -23,7 +23,7 @@ import {
   createTagAssignment,
   createTag,
   createStoryRelationship,
-  updateTaskById,
+  patchTaskById,
   createBucket,
 } from "../../ts/lib/api";
 import {
@@ -957,17 +957,12 @@ export function CopyToNewStory({
           task.status === TASK_STATUS.DOING,
       );
       for (const task of tasksToUpdate) {
-        // TODO (2023.06.26): update API to return the updated task
-        await updateTaskById(
-          task.id,
-          task.status,
-          task.title,
-          task.description,
-          story.id,
-        );
+        const savedTask = await patchTaskById(task.id, task.updated_at, {
+          story_id: story.id,
+        });
         setTasks((tasks) =>
           tasks.map((_task) =>
-            _task.id === task.id ? { ..._task, story_id: story.id } : _task,
+            _task.id === task.id ? { ..._task, ...savedTask } : _task,
           ),
         );
         setTaskMoveProgress((prog) => {
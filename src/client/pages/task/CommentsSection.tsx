@@ -107,12 +107,23 @@ export default function CommentsSection({
 
   function handleUpdateComment(commentId: number, newText: string) {
     void (async () => {
-      await updateCommentById(commentId, newText);
+      const comment = comments.find((c) => c.id === commentId);
+      if (comment === undefined) return;
+      const savedComment = await updateCommentById(
+        commentId,
+        newText,
+        comment.updated_at,
+      );
       // Update the comment locally in state instead of fetching all comments
       setComments((comments) =>
         comments.map((comment) =>
           comment.id === commentId
-            ? { ...comment, text: newText, edited: true }
+            ? {
+                ...comment,
+                text: newText,
+                edited: true,
+                updated_at: savedComment.updated_at,
+              }
             : comment,
         ),
       );
@@ -232,14 +232,16 @@ export default function TaskPage() {
     }
     if (!diff) return;
 
-    await updateTaskById(
+    const savedTask = await updateTaskById(
       updatedTask.id,
       updatedTask.status,
       updatedTask.title,
       updatedTask.description,
       updatedTask.story_id,
+      updatedTask.bucket_id,
+      task.updated_at,
     );
-    setTask(updatedTask);
+    setTask({ ...updatedTask, updated_at: savedTask.updated_at });
     broadcast({ type: "task-mutated", taskId: updatedTask.id });
   }
 
@@ -7,13 +7,23 @@ import {
   type Tag,
   type TagAssignment,
   type BucketTagAssignment,
+  type TaskTagAssignment,
+  type TaskTag,
+  type SearchResult,
+  type SavedView,
   type TaskComment,
+  type ChecklistItem,
   type Config,
   type StoryRelationship,
   type Bucket,
   STORY_RELATIONSHIP,
 } from "../model/entities";
-import type { CheckSessionRes } from "../model/responses";
+import type {
+  BulkUpdateResult,
+  CheckSessionRes,
+  Page,
+  ViewResults,
+} from "../model/responses";
 import { showToast } from "./api_utils";
 
 const routes = {
@@ -25,9 +35,12 @@ const routes = {
   getTasks: "/api/get_tasks",
   createTask: "/api/create_task",
   updateTask: "/api/put_task",
+  patchTask: "/api/patch_task",
   updateStory: "/api/put_story",
+  patchStory: "/api/patch_story",
   getStories: "/api/get_stories",
   getSprints: "/api/get_sprints",
+  getCurrentSprint: "/api/get_current_sprint",
   createStory: "/api/create_story",
   createSprint: "/api/create_sprint",
 
@@ -35,6 +48,11 @@ const routes = {
   getCommentsByTaskId: "/api/get_comments_by_task_id",
   createComment: "/api/create_comment",
   updateComment: "/api/put_comment",
+  getChecklistItems: "/api/get_checklist_items",
+  createChecklistItem: "/api/create_checklist_item",
+  updateChecklistItem: "/api/put_checklist_item",
+  reorderChecklistItems: "/api/reorder_checklist_items",
+  destroyChecklistItem: "/api/destroy_checklist_item",
   getStoryById: "/api/get_story",
 
   getTags: "/api/get_tags",
@@ -55,10 +73,40 @@ const routes = {
   getBucketTagAssignments: "/api/get_bucket_tag_assignments",
   createBucketTagAssignment: "/api/create_bucket_tag_assignment",
   destroyBucketTagAssignmentById: "/api/destroy_bucket_tag_assignment_by_id",
+
+  getTaskTagAssignments: "/api/get_task_tag_assignments",
+  getTaskTags: "/api/get_task_tags",
+  createTaskTagAssignment: "/api/create_task_tag_assignment",
+  destroyTaskTagAssignmentById: "/api/destroy_task_tag_assignment_by_id",
+
+  search: "/api/search",
+
+  getSavedViews: "/api/get_saved_views",
+  createSavedView: "/api/create_saved_view",
+  getViewResults: "/api/get_view_results",
+
+  bulkUpdate: "/api/bulk_update",
 };
 
 // API
 
+// follows next_cursor through every page of a GetMany api
+async function getAllPages<T>(route: string): Promise<T[]> {
+  const items: T[] = [];
+  let cursor: string | null = null;
+  do {
+    const url: string =
+      cursor === null
+        ? route
+        : `${route}?${new URLSearchParams({ cursor }).toString()}`;
+    const res = await fetch(url, { method: "GET" });
+    const page = (await handleApiRes(res)) as Page<T>;
+    items.push(...page.items);
+    cursor = page.next_cursor;
+  } while (cursor !== null);
+  return items;
+}
+
 // I currently want to bail if anything goes wrong
 export async function checkSession(): Promise<CheckSessionRes> {
   try {
@@ -92,8 +140,7 @@ export async function getConfig(): Promise<Config> {
 
 export async function getTasks(): Promise<Task[]> {
   try {
-    const res = await fetch(routes.getTasks, { method: "GET" });
-    return (await handleApiRes(res)) as Task[];
+    return await getAllPages<Task>(routes.getTasks);
   } catch (err) {
     if (err instanceof Error) handleApiErr(err);
     throw err;
@@ -102,8 +149,7 @@ export async function getTasks(): Promise<Task[]> {
 
 export async function getStories(): Promise<Story[]> {
   try {
-    const res = await fetch(routes.getStories, { method: "GET" });
-    return (await handleApiRes(res)) as Story[];
+    return await getAllPages<Story>(routes.getStories);
   } catch (err) {
     if (err instanceof Error) handleApiErr(err);
     throw err;
@@ -112,8 +158,19 @@ export async function getStories(): Promise<Story[]> {
 
 export async function getSprints(): Promise<Sprint[]> {
   try {
-    const res = await fetch(routes.getSprints, { method: "GET" });
-    return (await handleApiRes(res)) as Sprint[];
+    return await getAllPages<Sprint>(routes.getSprints);
+  } catch (err) {
+    if (err instanceof Error) handleApiErr(err);
+    throw err;
+  }
+}
+
+// resolves to null if no sprint includes today
+export async function getCurrentSprint(): Promise<Sprint | null> {
+  try {
+    const res = await fetch(routes.getCurrentSprint, { method: "GET" });
+    if (res.status === 404) return null;
+    return (await handleApiRes(res)) as Sprint;
   } catch (err) {
     if (err instanceof Error) handleApiErr(err);
     throw err;
@@ -122,8 +179,7 @@ export async function getSprints(): Promise<Sprint[]> {
 
 export async function getTags(): Promise<Tag[]> {
   try {
-    const res = await fetch(routes.getTags, { method: "GET" });
-    return (await handleApiRes(res)) as Tag[];
+    return await getAllPages<Tag>(routes.getTags);
   } catch (err) {
     if (err instanceof Error) handleApiErr(err);
     throw err;
@@ -219,6 +275,7 @@ export async function createSprint(
 export async function createTag(
   title: string,
   description: string,
+  parentId: string | null = null,
 ): Promise<Tag> {
   const res = await fetch(routes.createTag, {
     method: "POST",
@@ -228,6 +285,7 @@ export async function createTag(
     body: JSON.stringify({
       title,
       description,
+      parent_id: parentId,
     }),
   });
   return (await handleApiRes(res)) as Tag;
@@ -305,7 +363,9 @@ export async function updateTaskById(
   title: string,
   description: string,
   storyId: string | null,
-): Promise<JSON> {
+  bucketId: string | null,
+  updatedAt: string,
+): Promise<Task> {
   try {
     const res = await fetch(routes.updateTask, {
       method: "PUT",
@@ -318,9 +378,38 @@ export async function updateTaskById(
         title,
         description,
         story_id: storyId,
+        bucket_id: bucketId,
+        updated_at: updatedAt,
       }),
     });
-    return await handleApiRes(res);
+    return (await handleApiRes(res)) as Task;
+  } catch (err) {
+    if (err instanceof Error) handleApiErr(err);
+    throw err;
+  }
+}
+
+// only the fields in `fields` are changed; the rest are left as they are
+export async function patchTaskById(
+  id: string,
+  updatedAt: string,
+  fields: Partial<
+    Pick<Task, "status" | "title" | "description" | "story_id" | "bucket_id">
+  >,
+): Promise<Task> {
+  try {
+    const res = await fetch(routes.patchTask, {
+      method: "PATCH",
+      headers: {
+        "Content-Type": "application/json",
+      },
+      body: JSON.stringify({
+        id,
+        ...fields,
+        updated_at: updatedAt,
+      }),
+    });
+    return (await handleApiRes(res)) as Task;
   } catch (err) {
     if (err instanceof Error) handleApiErr(err);
     throw err;
@@ -333,7 +422,8 @@ export async function updateStoryById(
   title: string,
   description: string,
   sprintId: string,
-): Promise<JSON> {
+  updatedAt: string,
+): Promise<Story> {
   try {
     const res = await fetch(routes.updateStory, {
       method: "PUT",
@@ -346,9 +436,37 @@ export async function updateStoryById(
         title,
         description,
         sprint_id: sprintId,
+        updated_at: updatedAt,
       }),
     });
-    return await handleApiRes(res);
+    return (await handleApiRes(res)) as Story;
+  } catch (err) {
+    if (err instanceof Error) handleApiErr(err);
+    throw err;
+  }
+}
+
+// only the fields in `fields` are changed; the rest are left as they are
+export async function patchStoryById(
+  id: string,
+  updatedAt: string,
+  fields: Partial<
+    Pick<Story, "status" | "title" | "description" | "sprint_id">
+  >,
+): Promise<Story> {
+  try {
+    const res = await fetch(routes.patchStory, {
+      method: "PATCH",
+      headers: {
+        "Content-Type": "application/json",
+      },
+      body: JSON.stringify({
+        id,
+        ...fields,
+        updated_at: updatedAt,
+      }),
+    });
+    return (await handleApiRes(res)) as Story;
   } catch (err) {
     if (err instanceof Error) handleApiErr(err);
     throw err;
@@ -358,7 +476,8 @@ export async function updateStoryById(
 export async function updateCommentById(
   id: number,
   text: string,
-): Promise<JSON> {
+  updatedAt: string,
+): Promise<TaskComment> {
   try {
     const res = await fetch(routes.updateComment, {
       method: "PUT",
@@ -368,9 +487,10 @@ export async function updateCommentById(
       body: JSON.stringify({
         id,
         text,
+        updated_at: updatedAt,
       }),
     });
-    return await handleApiRes(res);
+    return (await handleApiRes(res)) as TaskComment;
   } catch (err) {
     if (err instanceof Error) handleApiErr(err);
     throw err;
@@ -498,6 +618,58 @@ export async function destroyBucketTagAssignmentById(
   return await handleApiRes(res);
 }
 
+export async function getTaskTagAssignments(): Promise<TaskTagAssignment[]> {
+  try {
+    const res = await fetch(routes.getTaskTagAssignments, { method: "GET" });
+    return (await handleApiRes(res)) as TaskTagAssignment[];
+  } catch (err) {
+    if (err instanceof Error) handleApiErr(err);
+    throw err;
+  }
+}
+
+// tags of a single task, or of every task if taskId is null;
+// with inherit, tags of the task's story and bucket are included
+export async function getTaskTags(
+  taskId: string | null,
+  inherit: boolean,
+): Promise<TaskTag[]> {
+  const params = new URLSearchParams({ inherit: String(inherit) });
+  if (taskId !== null) params.set("task_id", taskId);
+  try {
+    const res = await fetch(`${routes.getTaskTags}?${params.toString()}`, {
+      method: "GET",
+    });
+    return (await handleApiRes(res)) as TaskTag[];
+  } catch (err) {
+    if (err instanceof Error) handleApiErr(err);
+    throw err;
+  }
+}
+
+export async function createTaskTagAssignment(
+  tagId: string,
+  taskId: string,
+): Promise<TaskTagAssignment> {
+  const res = await fetch(routes.createTaskTagAssignment, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ tag_id: tagId, task_id: taskId }),
+  });
+  return (await handleApiRes(res)) as TaskTagAssignment;
+}
+
+export async function destroyTaskTagAssignmentById(
+  id: number,
+): Promise<JSON> {
+  const res = await fetch(routes.destroyTaskTagAssignmentById, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ id }),
+  });
+  return await handleApiRes(res);
+}
+
 export async function getStoryRelationships(): Promise<StoryRelationship[]> {
   try {
     const res = await fetch(routes.getStoryRelationships, { method: "GET" });
@@ -534,3 +706,153 @@ function handleApiErr(err: Error) {
   console.error("(handleApiErr) error occurred:", err);
   showToast(err.message);
 }
+
+export async function search(
+  query: string,
+  kinds: SearchResult["kind"][] = [],
+): Promise<SearchResult[]> {
+  const params = new URLSearchParams({ q: query });
+  if (kinds.length > 0) params.set("kind", kinds.join(","));
+  try {
+    const res = await fetch(`${routes.search}?${params.toString()}`, {
+      method: "GET",
+    });
+    return (await handleApiRes(res)) as SearchResult[];
+  } catch (err) {
+    if (err instanceof Error) handleApiErr(err);
+    throw err;
+  }
+}
+
+export async function getSavedViews(): Promise<SavedView[]> {
+  try {
+    const res = await fetch(routes.getSavedViews, { method: "GET" });
+    return (await handleApiRes(res)) as SavedView[];
+  } catch (err) {
+    if (err instanceof Error) handleApiErr(err);
+    throw err;
+  }
+}
+
+export async function createSavedView(
+  name: string,
+  entityKind: SavedView["entity_kind"],
+  filter: SavedView["filter"],
+): Promise<SavedView> {
+  const res = await fetch(routes.createSavedView, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ name, entity_kind: entityKind, filter }),
+  });
+  return (await handleApiRes(res)) as SavedView;
+}
+
+export async function getViewResults(
+  id: string,
+  cursor: string | null = null,
+): Promise<ViewResults> {
+  const params = new URLSearchParams({ id });
+  if (cursor !== null) params.set("cursor", cursor);
+  try {
+    const res = await fetch(`${routes.getViewResults}?${params.toString()}`, {
+      method: "GET",
+    });
+    return (await handleApiRes(res)) as ViewResults;
+  } catch (err) {
+    if (err instanceof Error) handleApiErr(err);
+    throw err;
+  }
+}
+
+// applies one change set to many tasks or stories; nothing is changed
+// unless the result is applied.  Omitted fields are left unchanged.
+export async function bulkUpdate(
+  entityKind: "task" | "story",
+  ids: string[],
+  changes: {
+    status?: string;
+    story_id?: string | null;
+    bucket_id?: string | null;
+    add_tag_ids?: string[];
+    remove_tag_ids?: string[];
+  },
+): Promise<BulkUpdateResult> {
+  const res = await fetch(routes.bulkUpdate, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ entity_kind: entityKind, ids, ...changes }),
+  });
+  return (await handleApiRes(res)) as BulkUpdateResult;
+}
+
+export async function getChecklistItems(
+  taskId: string,
+): Promise<ChecklistItem[]> {
+  try {
+    const res = await fetch(`${routes.getChecklistItems}?task_id=${taskId}`, {
+      method: "GET",
+    });
+    return (await handleApiRes(res)) as ChecklistItem[];
+  } catch (err) {
+    if (err instanceof Error) handleApiErr(err);
+    throw err;
+  }
+}
+
+export async function createChecklistItem(
+  taskId: string,
+  text: string,
+): Promise<ChecklistItem> {
+  const res = await fetch(routes.createChecklistItem, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ task_id: taskId, text }),
+  });
+  return (await handleApiRes(res)) as ChecklistItem;
+}
+
+export async function updateChecklistItem(
+  id: number,
+  text: string,
+  done: boolean,
+  updatedAt: string,
+): Promise<ChecklistItem> {
+  try {
+    const res = await fetch(routes.updateChecklistItem, {
+      method: "PUT",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ id, text, done, updated_at: updatedAt }),
+    });
+    return (await handleApiRes(res)) as ChecklistItem;
+  } catch (err) {
+    if (err instanceof Error) handleApiErr(err);
+    throw err;
+  }
+}
+
+// itemIds must list every item of the task, in the new order
+export async function reorderChecklistItems(
+  taskId: string,
+  itemIds: number[],
+): Promise<ChecklistItem[]> {
+  try {
+    const res = await fetch(routes.reorderChecklistItems, {
+      method: "PUT",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ task_id: taskId, item_ids: itemIds }),
+    });
+    return (await handleApiRes(res)) as ChecklistItem[];
+  } catch (err) {
+    if (err instanceof Error) handleApiErr(err);
+    throw err;
+  }
+}
+
+export async function destroyChecklistItem(id: number): Promise<JSON> {
+  const res = await fetch(routes.destroyChecklistItem, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ id }),
+  });
+  return await handleApiRes(res);
+}
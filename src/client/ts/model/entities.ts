@@ -22,6 +22,10 @@ export enum STORY_STATUS {
 
 export enum STORY_RELATIONSHIP {
   ContinuedBy = "CONTINUED_BY",
+  Blocks = "BLOCKS",
+  RelatesTo = "RELATES_TO",
+  Duplicates = "DUPLICATES",
+  ParentOf = "PARENT_OF",
 }
 
 export enum BUCKET_STATUS {
@@ -58,6 +62,18 @@ export interface Task {
   edited: boolean;
   bulk_task: boolean;
   comment_count?: number;
+  checklist?: { done: number; total: number };
+}
+
+export interface ChecklistItem {
+  id: number;
+  task_id: string;
+  created_at: string;
+  updated_at: string;
+  text: string;
+  done: boolean;
+  position: number;
+  edited: boolean;
 }
 
 export interface TaskComment {
@@ -75,6 +91,7 @@ export interface Tag {
   updated_at: string;
   title: string;
   description: string;
+  parent_id: string | null;
   is_parent: boolean;
   edited: boolean;
 }
@@ -86,6 +103,20 @@ export interface TagAssignment {
   story_id: string;
 }
 
+export interface TaskTagAssignment {
+  id: number;
+  created_at: string;
+  tag_id: string;
+  task_id: string;
+}
+
+// a tag of a task, possibly inherited from its story or bucket
+export interface TaskTag {
+  task_id: string;
+  tag_id: string;
+  source: "task" | "story" | "bucket";
+}
+
 export interface BucketTagAssignment {
   id: number;
   created_at: string;
@@ -133,3 +164,27 @@ export interface StoryRelationship {
   story_id_b: string;
   relation: string;
 }
+
+// snippet is HTML escaped user text with matches wrapped in <mark></mark>
+export interface SearchResult {
+  kind: "task" | "story" | "bucket" | "comment";
+  id: string;
+  sqid: string;
+  title: string;
+  status: string;
+  sprint_id: string | null;
+  rank: number;
+  snippet: string;
+}
+
+// filter holds get_tasks or get_stories query parameters;
+// a sprint_id of "current" follows the current sprint
+export interface SavedView {
+  id: string;
+  created_at: string;
+  updated_at: string;
+  name: string;
+  entity_kind: "task" | "story";
+  filter: Record<string, string[]>;
+  edited: boolean;
+}
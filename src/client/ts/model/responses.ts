@@ -1,3 +1,29 @@
+import type { SavedView, Story, Task } from "./entities";
+
 export interface CheckSessionRes {
   session_time_remaining_seconds: number;
 }
+
+// one page of a GetMany api; next_cursor is null on the last page
+export interface Page<T> {
+  items: T[];
+  next_cursor: string | null;
+}
+
+// exactly one of tasks and stories is set, according to view.entity_kind
+export interface ViewResults {
+  view: SavedView;
+  tasks?: Page<Task>;
+  stories?: Page<Story>;
+}
+
+export interface BulkUpdateResult {
+  applied: boolean;
+  items: {
+    id: string;
+    error?: string;
+    task?: Task;
+    story?: Story;
+    warnings?: string[];
+  }[];
+}
@@ -2,7 +2,12 @@ package cache
 
 import (
 	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,53 +24,275 @@ const (
 )
 
 type item struct {
+	key       string
 	value     []byte
+	etag      string
 	createdAt int64
+	// deps are the entity kinds this response was built from
+	deps []string
 }
 
-// Store is a thread-safe in-memory cache with a configurable TTL.
+// Stats is a point-in-time snapshot of the Store's counters.
+type Stats struct {
+	Hits          uint64 `json:"hits"`
+	Misses        uint64 `json:"misses"`
+	Evictions     uint64 `json:"evictions"`
+	Expirations   uint64 `json:"expirations"`
+	Invalidations uint64 `json:"invalidations"`
+	Entries       int    `json:"entries"`
+	Bytes         int64  `json:"bytes"`
+	MaxEntries    int    `json:"max_entries"`
+	MaxBytes      int64  `json:"max_bytes"`
+}
+
+// Store is a thread-safe in-memory LRU cache with a configurable TTL,
+// bounded by both entry count and total bytes held.
+// Entries are additionally evicted when an entity kind they depend on is written.
 type Store struct {
-	sync.RWMutex
-	items map[string]*item
-	ttl   time.Duration
+	sync.Mutex
+	items      map[string]*list.Element
+	lru        *list.List // front is most recently used
+	ttl        time.Duration
+	maxEntries int
+	maxBytes   int64
+	stats      Stats
+	// generations is bumped for a kind on every invalidation so that
+	// a read which started before a write doesn't cache stale data
+	generations map[string]uint64
+
+	janitorTicker *time.Ticker
+	stopChan      chan struct{}
+}
+
+// NewStore creates a new cache Store with the given TTL and limits,
+// and starts a janitor which sweeps expired items every janitorInterval.
+// A limit <= 0 means unbounded.
+func NewStore(ttl, janitorInterval time.Duration, maxEntries int, maxBytes int64) *Store {
+	s := &Store{
+		items:         make(map[string]*list.Element),
+		lru:           list.New(),
+		ttl:           ttl,
+		maxEntries:    maxEntries,
+		maxBytes:      maxBytes,
+		generations:   make(map[string]uint64),
+		janitorTicker: time.NewTicker(janitorInterval),
+		stopChan:      make(chan struct{}),
+	}
+	go s.janitor()
+	return s
+}
+
+// Stop shuts down the janitor
+func (s *Store) Stop() {
+	close(s.stopChan)
+	s.janitorTicker.Stop()
 }
 
-// NewStore creates a new cache Store with the given TTL.
-func NewStore(ttl time.Duration) *Store {
-	return &Store{
-		items: make(map[string]*item),
-		ttl:   ttl,
+func (s *Store) janitor() {
+	for {
+		select {
+		case <-s.janitorTicker.C:
+			s.DeleteExpired()
+		case <-s.stopChan:
+			return
+		}
 	}
 }
 
-// Get retrieves a cached value by key. Returns false if not found or expired.
-func (s *Store) Get(key string) ([]byte, bool) {
-	s.RLock()
-	defer s.RUnlock()
+func (s *Store) expired(it *item, now int64) bool {
+	return now > it.createdAt+int64(s.ttl.Seconds())
+}
+
+// removeElement must be called with the lock held
+func (s *Store) removeElement(el *list.Element) {
+	it := el.Value.(*item)
+	s.lru.Remove(el)
+	delete(s.items, it.key)
+	s.stats.Bytes -= int64(len(it.value))
+}
 
-	it, found := s.items[key]
+// Get retrieves a cached value and its ETag by key. Returns false if not found or expired.
+func (s *Store) Get(key string) ([]byte, string, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	el, found := s.items[key]
 	if !found {
-		return nil, false
+		s.stats.Misses++
+		return nil, "", false
 	}
 
-	if time.Now().Unix() > it.createdAt+int64(s.ttl.Seconds()) {
-		return nil, false
+	it := el.Value.(*item)
+	if s.expired(it, time.Now().Unix()) {
+		s.removeElement(el)
+		s.stats.Expirations++
+		s.stats.Misses++
+		return nil, "", false
 	}
 
-	return it.value, true
+	s.lru.MoveToFront(el)
+	s.stats.Hits++
+	return it.value, it.etag, true
 }
 
-// Set stores a value in the cache.
-func (s *Store) Set(key string, value []byte) {
+// Set stores a value and its ETag in the cache, tagged with the entity kinds it depends on.
+func (s *Store) Set(key string, value []byte, etag string, deps ...string) {
 	s.Lock()
 	defer s.Unlock()
 
-	s.items[key] = &item{
+	s.set(key, value, etag, deps)
+}
+
+// set must be called with the lock held
+func (s *Store) set(key string, value []byte, etag string, deps []string) {
+	// a single value larger than the whole budget is never cached
+	if s.maxBytes > 0 && int64(len(value)) > s.maxBytes {
+		return
+	}
+
+	if el, found := s.items[key]; found {
+		s.removeElement(el)
+	}
+
+	it := &item{
+		key:       key,
 		value:     value,
+		etag:      etag,
 		createdAt: time.Now().Unix(),
+		deps:      deps,
+	}
+	s.items[key] = s.lru.PushFront(it)
+	s.stats.Bytes += int64(len(value))
+
+	for (s.maxEntries > 0 && s.lru.Len() > s.maxEntries) ||
+		(s.maxBytes > 0 && s.stats.Bytes > s.maxBytes) {
+		s.removeElement(s.lru.Back())
+		s.stats.Evictions++
+	}
+}
+
+// DeleteExpired removes every expired item from the store.
+func (s *Store) DeleteExpired() {
+	s.Lock()
+	defer s.Unlock()
+
+	now := time.Now().Unix()
+	for el := s.lru.Back(); el != nil; {
+		prev := el.Prev()
+		if s.expired(el.Value.(*item), now) {
+			s.removeElement(el)
+			s.stats.Expirations++
+		}
+		el = prev
 	}
 }
 
+// Stats returns a snapshot of the store's counters.
+func (s *Store) Stats() Stats {
+	s.Lock()
+	defer s.Unlock()
+
+	stats := s.stats
+	stats.Entries = s.lru.Len()
+	stats.MaxEntries = s.maxEntries
+	stats.MaxBytes = s.maxBytes
+	return stats
+}
+
+// Invalidate evicts every entry which depends on any of the given entity kinds.
+func (s *Store) Invalidate(kinds ...string) {
+	if len(kinds) == 0 {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	for _, kind := range kinds {
+		s.generations[kind]++
+	}
+
+	for _, el := range s.items {
+		if dependsOnAny(el.Value.(*item).deps, kinds) {
+			s.removeElement(el)
+			s.stats.Invalidations++
+		}
+	}
+}
+
+// snapshot returns the current generation of each kind
+func (s *Store) snapshot(kinds []string) []uint64 {
+	s.Lock()
+	defer s.Unlock()
+
+	gens := make([]uint64, len(kinds))
+	for i, kind := range kinds {
+		gens[i] = s.generations[kind]
+	}
+	return gens
+}
+
+// setIfUnchanged stores a value only if none of its deps have been
+// invalidated since gens was taken.
+func (s *Store) setIfUnchanged(key string, value []byte, etag string, deps []string, gens []uint64) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	for i, kind := range deps {
+		if s.generations[kind] != gens[i] {
+			return false
+		}
+	}
+
+	s.set(key, value, etag, deps)
+	return true
+}
+
+// Key returns the cache key for a request URL.  Query parameters are
+// part of the key, sorted so that their order does not matter.
+func Key(u *url.URL) string {
+	query := u.Query().Encode()
+	if query == "" {
+		return u.Path
+	}
+	return u.Path + "?" + query
+}
+
+// ETag returns a strong entity tag derived from the response body.
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ETagMatches reports whether an If-None-Match header value matches etag.
+// Per RFC 9110, If-None-Match uses weak comparison.
+func ETagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func dependsOnAny(deps, kinds []string) bool {
+	for _, d := range deps {
+		for _, k := range kinds {
+			if d == k {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // ResponseWriter wraps http.ResponseWriter to track cache status.
 type ResponseWriter struct {
 	http.ResponseWriter
@@ -97,10 +324,22 @@ func (rr *responseRecorder) Write(content []byte) (int, error) {
 	return rr.ResponseWriter.Write(content)
 }
 
+// statusRecorder captures the status code of a response without buffering the body.
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	sr.statusCode = code
+	sr.ResponseWriter.WriteHeader(code)
+}
+
 // Middleware returns a caching middleware.
 // If cacheable is false, the request is marked as Skipped and passed through.
-// Otherwise it checks/populates the store.
-func (s *Store) Middleware(cacheable bool) utils.Middleware {
+// Otherwise it checks/populates the store, tagging the entry with reads.
+// A successful response evicts every entry depending on one of writes.
+func (s *Store) Middleware(cacheable bool, reads, writes []string) utils.Middleware {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			crw, ok := w.(*ResponseWriter)
@@ -111,25 +350,41 @@ func (s *Store) Middleware(cacheable bool) utils.Middleware {
 
 			if !cacheable {
 				*crw.StatusPtr = Skipped
-				h.ServeHTTP(w, r)
+				if len(writes) == 0 {
+					h.ServeHTTP(w, r)
+					return
+				}
+				recorder := &statusRecorder{w, http.StatusOK}
+				h.ServeHTTP(recorder, r)
+				if recorder.statusCode < http.StatusBadRequest {
+					s.Invalidate(writes...)
+				}
 				return
 			}
 
-			cacheKey := r.URL.String()
+			cacheKey := Key(r.URL)
 
-			if response, found := s.Get(cacheKey); found {
+			if response, etag, found := s.Get(cacheKey); found {
 				*crw.StatusPtr = Hit
+				if etag != "" {
+					w.Header().Set("ETag", etag)
+					if ETagMatches(r.Header.Get("If-None-Match"), etag) {
+						w.WriteHeader(http.StatusNotModified)
+						return
+					}
+				}
 				w.Header().Set("Content-Type", "application/json")
 				w.Write(response)
 				return
 			}
 
 			*crw.StatusPtr = Miss
+			gens := s.snapshot(reads)
 			recorder := &responseRecorder{w, new(bytes.Buffer), http.StatusOK}
 			h.ServeHTTP(recorder, r)
 
 			if recorder.statusCode == http.StatusOK {
-				s.Set(cacheKey, recorder.body.Bytes())
+				s.setIfUnchanged(cacheKey, recorder.body.Bytes(), recorder.Header().Get("ETag"), reads, gens)
 			}
 		})
 	}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const taskKind = "Task"
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s := NewStore(time.Minute, time.Minute, 0, 0)
+	t.Cleanup(s.Stop)
+	return s
+}
+
+// serve runs a request through h as the server does, wrapped in a
+// ResponseWriter, and returns the cache status and the response
+func serve(h http.Handler, method, target string) (Status, *httptest.ResponseRecorder) {
+	var status Status
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(NewResponseWriter(rec, &status), httptest.NewRequest(method, target, nil))
+	return status, rec
+}
+
+// taskAPIs returns a cached GET reading taskKind, which responds with the
+// current version, and an uncached write of taskKind, which bumps the version
+// and responds with writeStatus.  reads counts calls to the GET's handler.
+func taskAPIs(s *Store, writeStatus int) (get, write http.Handler, reads *int) {
+	version, n := 1, 0
+	get = s.Middleware(true, []string{taskKind}, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"version":%d}`, version)
+	}))
+	write = s.Middleware(false, nil, []string{taskKind})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if writeStatus != http.StatusOK {
+			http.Error(w, "something went wrong", writeStatus)
+			return
+		}
+		version++
+	}))
+	return get, write, &n
+}
+
+func TestReadIsHitOnSecondCall(t *testing.T) {
+	s := newTestStore(t)
+	get, _, reads := taskAPIs(s, http.StatusOK)
+
+	if status, _ := serve(get, http.MethodGet, "/api/get_tasks"); status != Miss {
+		t.Fatalf("first read: got %s, want %s", status, Miss)
+	}
+	status, rec := serve(get, http.MethodGet, "/api/get_tasks")
+	if status != Hit {
+		t.Fatalf("second read: got %s, want %s", status, Hit)
+	}
+	if got, want := rec.Body.String(), `{"version":1}`; got != want {
+		t.Errorf("second read: got body %s, want %s", got, want)
+	}
+	if *reads != 1 {
+		t.Errorf("handler ran %d times, want 1", *reads)
+	}
+}
+
+func TestWriteInvalidatesRead(t *testing.T) {
+	s := newTestStore(t)
+	get, write, _ := taskAPIs(s, http.StatusOK)
+
+	serve(get, http.MethodGet, "/api/get_tasks")
+	if status, _ := serve(write, http.MethodPost, "/api/create_task"); status != Skipped {
+		t.Fatalf("write: got %s, want %s", status, Skipped)
+	}
+	status, rec := serve(get, http.MethodGet, "/api/get_tasks")
+	if status != Miss {
+		t.Fatalf("read after write: got %s, want %s", status, Miss)
+	}
+	if got, want := rec.Body.String(), `{"version":2}`; got != want {
+		t.Errorf("read after write: got body %s, want %s", got, want)
+	}
+}
+
+func TestFailedWriteDoesNotInvalidate(t *testing.T) {
+	s := newTestStore(t)
+	get, write, reads := taskAPIs(s, http.StatusBadRequest)
+
+	serve(get, http.MethodGet, "/api/get_tasks")
+	if _, rec := serve(write, http.MethodPost, "/api/create_task"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("write: got code %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if status, _ := serve(get, http.MethodGet, "/api/get_tasks"); status != Hit {
+		t.Fatalf("read after failed write: got %s, want %s", status, Hit)
+	}
+	if s.Stats().Invalidations != 0 {
+		t.Errorf("got %d invalidations, want 0", s.Stats().Invalidations)
+	}
+	if *reads != 1 {
+		t.Errorf("handler ran %d times, want 1", *reads)
+	}
+}
+
+func TestReadRacingWriteIsNotCached(t *testing.T) {
+	s := newTestStore(t)
+	_, write, _ := taskAPIs(s, http.StatusOK)
+
+	// the write lands after the read has loaded its data but before it is cached
+	first := true
+	get := s.Middleware(true, []string{taskKind}, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if first {
+			first = false
+			serve(write, http.MethodPost, "/api/create_task")
+		}
+		w.Write([]byte(`{}`))
+	}))
+
+	serve(get, http.MethodGet, "/api/get_tasks")
+	if status, _ := serve(get, http.MethodGet, "/api/get_tasks"); status != Miss {
+		t.Fatalf("read after racing write: got %s, want %s", status, Miss)
+	}
+	if status, _ := serve(get, http.MethodGet, "/api/get_tasks"); status != Hit {
+		t.Fatalf("next read: got %s, want %s", status, Hit)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,6 +24,7 @@ import (
 	_ "image/jpeg"
 	_ "image/png"
 
+	"github.com/bschlaman/todo-app/cache"
 	"github.com/bschlaman/todo-app/model"
 	"github.com/google/uuid"
 )
@@ -160,6 +162,20 @@ func getConfigHandle() http.Handler {
 			return
 		}
 
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+// getCacheStatsHandle reports the api response cache counters
+func getCacheStatsHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		js, err := json.Marshal(apiCache.Stats())
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
 		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
 
 		w.Header().Set("Content-Type", "application/json")
@@ -184,10 +200,7 @@ func getCommentsByTaskIDHandle() http.Handler {
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
-
-		w.Header().Set("Content-Type", "application/json")
-		w.Write(js)
+		writeJSONWithETag(w, r, js)
 	})
 }
 
@@ -212,17 +225,25 @@ func getTaskByIDHandle() http.Handler {
 			return
 		}
 
-		js, err := json.Marshal(task)
+		// ?expand=blockers includes the tasks blocking this one
+		var entity interface{} = task
+		if r.URL.Query().Get("expand") == "blockers" {
+			blockers, err := model.GetTaskBlockers(env.Log, task.ID)
+			if err != nil {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+				return
+			}
+			entity = model.TaskWithBlockers{Task: *task, Blockers: blockers}
+		}
+
+		js, err := json.Marshal(entity)
 		if err != nil {
 			log.Errorf("json.Marshal failed: %v", err)
 			http.Error(w, "something went wrong", http.StatusInternalServerError)
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
-
-		w.Header().Set("Content-Type", "application/json")
-		w.Write(js)
+		writeJSONWithETag(w, r, js)
 	})
 }
 
@@ -243,7 +264,11 @@ func getStoryByIDHandle() http.Handler {
 		}
 
 		if err != nil {
-			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
 			return
 		}
 
@@ -254,16 +279,19 @@ func getStoryByIDHandle() http.Handler {
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
-
-		w.Header().Set("Content-Type", "application/json")
-		w.Write(js)
+		writeJSONWithETag(w, r, js)
 	})
 }
 
 func getTasksHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tasks, err := model.GetTasks(env.Log)
+		filter, err := model.ParseTaskFilter(env.Log, r.URL.Query())
+		if err != nil {
+			http.Error(w, "invalid filter", http.StatusBadRequest)
+			return
+		}
+
+		tasks, err := model.GetTasks(env.Log, filter)
 		if err != nil {
 			http.Error(w, "something went wrong", http.StatusInternalServerError)
 			return
@@ -276,10 +304,7 @@ func getTasksHandle() http.Handler {
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
-
-		w.Header().Set("Content-Type", "application/json")
-		w.Write(js)
+		writeJSONWithETag(w, r, js)
 	})
 }
 
@@ -295,7 +320,11 @@ func createTaskHandle() http.Handler {
 		task, err := model.CreateTask(env.Log, env.Sqids, createReq)
 		if err != nil {
 			log.Errorf("task creation failed: %v", err)
-			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
 			return
 		}
 
@@ -360,89 +389,79 @@ func putStoryHandle() http.Handler {
 			return
 		}
 
-		err := model.PutStory(env.Log, putReq)
+		entity, err := model.PutStory(env.Log, putReq)
 		if err != nil {
 			log.Errorf("story update failed: %v", err)
-			http.Error(w, "something went wrong", http.StatusInternalServerError)
-			return
-		}
-	})
-}
-
-func putTaskHandle() http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		putReq := model.PutTaskReq{}
-		if err := json.NewDecoder(r.Body).Decode(&putReq); err != nil {
-			log.Errorf("unable to decode json: %v", err)
-			http.Error(w, "something went wrong", http.StatusBadRequest)
+			var conflictErr model.ConflictError
+			if errors.As(err, &conflictErr) {
+				writeConflict(w, conflictErr)
+			} else if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
 			return
 		}
 
-		err := model.PutTask(env.Log, putReq)
+		js, err := json.Marshal(entity)
 		if err != nil {
-			log.Errorf("story update failed: %v", err)
+			log.Errorf("json.Marshal failed: %v", err)
 			http.Error(w, "something went wrong", http.StatusInternalServerError)
 			return
 		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
 	})
 }
 
-func putCommentHandle() http.Handler {
+func patchStoryHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		putReq := model.PutCommentReq{}
-		if err := json.NewDecoder(r.Body).Decode(&putReq); err != nil {
+		patchReq := model.PatchStoryReq{}
+		if err := json.NewDecoder(r.Body).Decode(&patchReq); err != nil {
 			log.Errorf("unable to decode json: %v", err)
 			http.Error(w, "something went wrong", http.StatusBadRequest)
 			return
 		}
 
-		err := model.PutComment(env.Log, putReq)
+		entity, err := model.PatchStory(env.Log, patchReq)
 		if err != nil {
-			log.Errorf("comment update failed: %v", err)
-			if errors.Is(err, model.InputError{}) {
+			log.Errorf("story patch failed: %v", err)
+			var conflictErr model.ConflictError
+			if errors.As(err, &conflictErr) {
+				writeConflict(w, conflictErr)
+			} else if errors.Is(err, model.InputError{}) {
 				http.Error(w, "something went wrong", http.StatusBadRequest)
 			} else {
 				http.Error(w, "something went wrong", http.StatusInternalServerError)
 			}
 			return
 		}
-	})
-}
-
-func getSprintsHandle() http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		sprints, err := model.GetSprints(env.Log)
-		if err != nil {
-			http.Error(w, "something went wrong", http.StatusInternalServerError)
-			return
-		}
 
-		js, err := json.Marshal(sprints)
+		js, err := json.Marshal(entity)
 		if err != nil {
 			log.Errorf("json.Marshal failed: %v", err)
 			http.Error(w, "something went wrong", http.StatusInternalServerError)
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
-
 		w.Header().Set("Content-Type", "application/json")
 		w.Write(js)
 	})
 }
 
-func createSprintHandle() http.Handler {
+func bulkUpdateHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		createReq := model.CreateSprintReq{}
-		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
+		updateReq := model.BulkUpdateReq{}
+		if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil {
 			log.Errorf("unable to decode json: %v", err)
 			http.Error(w, "something went wrong", http.StatusBadRequest)
 			return
 		}
 
-		entity, err := model.CreateSprint(env.Log, createReq)
+		result, err := model.BulkUpdate(env.Log, updateReq)
 		if err != nil {
-			log.Errorf("sprint creation failed: %v", err)
+			log.Errorf("bulk update failed: %v", err)
 			if errors.Is(err, model.InputError{}) {
 				http.Error(w, "something went wrong", http.StatusBadRequest)
 			} else {
@@ -451,62 +470,41 @@ func createSprintHandle() http.Handler {
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), createEntityIDKey, entity.ID))
-
-		js, err := json.Marshal(entity)
-		if err != nil {
-			log.Errorf("json.Marshal failed: %v", err)
-			http.Error(w, "something went wrong", http.StatusInternalServerError)
-			return
-		}
-
-		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
-
-		w.Header().Set("Content-Type", "application/json")
-		w.Write(js)
-	})
-}
-
-func getStoriesHandle() http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		stories, err := model.GetStories(env.Log)
-		if err != nil {
-			http.Error(w, "something went wrong", http.StatusInternalServerError)
-			return
-		}
-
-		js, err := json.Marshal(stories)
+		js, err := json.Marshal(result)
 		if err != nil {
 			log.Errorf("json.Marshal failed: %v", err)
 			http.Error(w, "something went wrong", http.StatusInternalServerError)
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
-
 		w.Header().Set("Content-Type", "application/json")
 		w.Write(js)
 	})
 }
 
-func createStoryHandle() http.Handler {
+func putTaskHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		createReq := model.CreateStoryReq{}
-		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
+		putReq := model.PutTaskReq{}
+		if err := json.NewDecoder(r.Body).Decode(&putReq); err != nil {
 			log.Errorf("unable to decode json: %v", err)
 			http.Error(w, "something went wrong", http.StatusBadRequest)
 			return
 		}
 
-		entity, err := model.CreateStory(env.Log, env.Sqids, createReq)
+		entity, err := model.PutTask(env.Log, putReq)
 		if err != nil {
-			log.Errorf("story creation failed: %v", err)
-			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			log.Errorf("task update failed: %v", err)
+			var conflictErr model.ConflictError
+			if errors.As(err, &conflictErr) {
+				writeConflict(w, conflictErr)
+			} else if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), createEntityIDKey, entity.ID))
-
 		js, err := json.Marshal(entity)
 		if err != nil {
 			log.Errorf("json.Marshal failed: %v", err)
@@ -514,71 +512,84 @@ func createStoryHandle() http.Handler {
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
-
 		w.Header().Set("Content-Type", "application/json")
 		w.Write(js)
 	})
 }
 
-// TAGS
-func getTagsHandle() http.Handler {
+func patchTaskHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tags, err := model.GetTags(env.Log)
+		patchReq := model.PatchTaskReq{}
+		if err := json.NewDecoder(r.Body).Decode(&patchReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		entity, err := model.PatchTask(env.Log, patchReq)
 		if err != nil {
-			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			log.Errorf("task patch failed: %v", err)
+			var conflictErr model.ConflictError
+			if errors.As(err, &conflictErr) {
+				writeConflict(w, conflictErr)
+			} else if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
 			return
 		}
 
-		js, err := json.Marshal(tags)
+		js, err := json.Marshal(entity)
 		if err != nil {
 			log.Errorf("json.Marshal failed: %v", err)
 			http.Error(w, "something went wrong", http.StatusInternalServerError)
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
-
 		w.Header().Set("Content-Type", "application/json")
 		w.Write(js)
 	})
 }
 
-func getTagAssignmentsHandle() http.Handler {
+func destroyTaskHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tagAssignments, err := model.GetTagAssignments(env.Log)
-		if err != nil {
-			http.Error(w, "something went wrong", http.StatusInternalServerError)
+		destroyReq := model.DestroyTaskReq{}
+		if err := json.NewDecoder(r.Body).Decode(&destroyReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
 			return
 		}
 
-		js, err := json.Marshal(tagAssignments)
+		err := model.DestroyTask(env.Log, destroyReq)
 		if err != nil {
-			log.Errorf("json.Marshal failed: %v", err)
-			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			log.Errorf("task destruction failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
 			return
 		}
-
-		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
-
-		w.Header().Set("Content-Type", "application/json")
-		w.Write(js)
 	})
 }
 
-func createTagAssignmentHandle() http.Handler {
+func putCommentHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		createReq := model.CreateTagAssignmentReq{}
-		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
+		putReq := model.PutCommentReq{}
+		if err := json.NewDecoder(r.Body).Decode(&putReq); err != nil {
 			log.Errorf("unable to decode json: %v", err)
 			http.Error(w, "something went wrong", http.StatusBadRequest)
 			return
 		}
 
-		entity, err := model.CreateTagAssignment(env.Log, createReq)
+		entity, err := model.PutComment(env.Log, putReq)
 		if err != nil {
-			log.Errorf("tag assignment creation failed: %v", err)
-			if errors.Is(err, model.InputError{}) {
+			log.Errorf("comment update failed: %v", err)
+			var conflictErr model.ConflictError
+			if errors.As(err, &conflictErr) {
+				writeConflict(w, conflictErr)
+			} else if errors.Is(err, model.InputError{}) {
 				http.Error(w, "something went wrong", http.StatusBadRequest)
 			} else {
 				http.Error(w, "something went wrong", http.StatusInternalServerError)
@@ -586,8 +597,6 @@ func createTagAssignmentHandle() http.Handler {
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), createEntityIDKey, entity.ID))
-
 		js, err := json.Marshal(entity)
 		if err != nil {
 			log.Errorf("json.Marshal failed: %v", err)
@@ -595,25 +604,23 @@ func createTagAssignmentHandle() http.Handler {
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
-
 		w.Header().Set("Content-Type", "application/json")
 		w.Write(js)
 	})
 }
 
-func destroyTagAssignmentHandle() http.Handler {
+func destroyCommentHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		destroyReq := model.DestroyTagAssignmentReq{}
+		destroyReq := model.DestroyCommentReq{}
 		if err := json.NewDecoder(r.Body).Decode(&destroyReq); err != nil {
 			log.Errorf("unable to decode json: %v", err)
 			http.Error(w, "something went wrong", http.StatusBadRequest)
 			return
 		}
 
-		err := model.DestroyTagAssignment(env.Log, destroyReq)
+		err := model.DestroyComment(env.Log, destroyReq)
 		if err != nil {
-			log.Errorf("tag assignment destruction failed: %v", err)
+			log.Errorf("comment destruction failed: %v", err)
 			if errors.Is(err, model.InputError{}) {
 				http.Error(w, "something went wrong", http.StatusBadRequest)
 			} else {
@@ -624,18 +631,12 @@ func destroyTagAssignmentHandle() http.Handler {
 	})
 }
 
-func destroyTagAssignmentByIDHandle() http.Handler {
+func getChecklistItemsHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		destroyReq := model.DestroyTagAssignmentByIDReq{}
-		if err := json.NewDecoder(r.Body).Decode(&destroyReq); err != nil {
-			log.Errorf("unable to decode json: %v", err)
-			http.Error(w, "something went wrong", http.StatusBadRequest)
-			return
-		}
+		taskID := r.URL.Query().Get("task_id")
 
-		err := model.DestroyTagAssignmentByID(env.Log, destroyReq)
+		items, err := model.GetChecklistItems(env.Log, taskID)
 		if err != nil {
-			log.Errorf("tag assignment destruction failed: %v", err)
 			if errors.Is(err, model.InputError{}) {
 				http.Error(w, "something went wrong", http.StatusBadRequest)
 			} else {
@@ -643,21 +644,30 @@ func destroyTagAssignmentByIDHandle() http.Handler {
 			}
 			return
 		}
+
+		js, err := json.Marshal(items)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSONWithETag(w, r, js)
 	})
 }
 
-func createTagHandle() http.Handler {
+func createChecklistItemHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		createReq := model.CreateTagReq{}
+		createReq := model.CreateChecklistItemReq{}
 		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
 			log.Errorf("unable to decode json: %v", err)
 			http.Error(w, "something went wrong", http.StatusBadRequest)
 			return
 		}
 
-		entity, err := model.CreateTag(env.Log, createReq)
+		item, err := model.CreateChecklistItem(env.Log, createReq)
 		if err != nil {
-			log.Errorf("tag creation failed: %v", err)
+			log.Errorf("checklist item creation failed: %v", err)
 			if errors.Is(err, model.InputError{}) {
 				http.Error(w, "something went wrong", http.StatusBadRequest)
 			} else {
@@ -666,9 +676,9 @@ func createTagHandle() http.Handler {
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), createEntityIDKey, entity.ID))
+		*r = *r.WithContext(context.WithValue(r.Context(), createEntityIDKey, item.ID))
 
-		js, err := json.Marshal(entity)
+		js, err := json.Marshal(item)
 		if err != nil {
 			log.Errorf("json.Marshal failed: %v", err)
 			http.Error(w, "something went wrong", http.StatusInternalServerError)
@@ -682,40 +692,54 @@ func createTagHandle() http.Handler {
 	})
 }
 
-func getStoryRelationshipsHandle() http.Handler {
+func putChecklistItemHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		StoryRelationships, err := model.GetStoryRelationships(env.Log)
+		putReq := model.PutChecklistItemReq{}
+		if err := json.NewDecoder(r.Body).Decode(&putReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		entity, err := model.PutChecklistItem(env.Log, putReq)
 		if err != nil {
-			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			log.Errorf("checklist item update failed: %v", err)
+			var conflictErr model.ConflictError
+			if errors.As(err, &conflictErr) {
+				writeConflict(w, conflictErr)
+			} else if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
 			return
 		}
 
-		js, err := json.Marshal(StoryRelationships)
+		js, err := json.Marshal(entity)
 		if err != nil {
 			log.Errorf("json.Marshal failed: %v", err)
 			http.Error(w, "something went wrong", http.StatusInternalServerError)
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
-
 		w.Header().Set("Content-Type", "application/json")
 		w.Write(js)
 	})
 }
 
-func createStoryRelationshipHandle() http.Handler {
+// reorderChecklistItemsHandle returns the task's checklist in its new order
+func reorderChecklistItemsHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		createReq := model.CreateStoryRelationshipReq{}
-		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
+		reorderReq := model.ReorderChecklistItemsReq{}
+		if err := json.NewDecoder(r.Body).Decode(&reorderReq); err != nil {
 			log.Errorf("unable to decode json: %v", err)
 			http.Error(w, "something went wrong", http.StatusBadRequest)
 			return
 		}
 
-		entity, err := model.CreateStoryRelationship(env.Log, createReq)
+		items, err := model.ReorderChecklistItems(env.Log, reorderReq)
 		if err != nil {
-			log.Errorf("story relationship creation failed: %v", err)
+			log.Errorf("checklist reorder failed: %v", err)
 			if errors.Is(err, model.InputError{}) {
 				http.Error(w, "something went wrong", http.StatusBadRequest)
 			} else {
@@ -724,34 +748,30 @@ func createStoryRelationshipHandle() http.Handler {
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), createEntityIDKey, entity.ID))
-
-		js, err := json.Marshal(entity)
+		js, err := json.Marshal(items)
 		if err != nil {
 			log.Errorf("json.Marshal failed: %v", err)
 			http.Error(w, "something went wrong", http.StatusInternalServerError)
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
-
 		w.Header().Set("Content-Type", "application/json")
 		w.Write(js)
 	})
 }
 
-func destroyStoryRelationshipByIDHandle() http.Handler {
+func destroyChecklistItemHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		destroyReq := model.DestroyStoryRelationshipByIDReq{}
+		destroyReq := model.DestroyChecklistItemReq{}
 		if err := json.NewDecoder(r.Body).Decode(&destroyReq); err != nil {
 			log.Errorf("unable to decode json: %v", err)
 			http.Error(w, "something went wrong", http.StatusBadRequest)
 			return
 		}
 
-		err := model.DestroyStoryRelationshipByID(env.Log, destroyReq)
+		err := model.DestroyChecklistItem(env.Log, destroyReq)
 		if err != nil {
-			log.Errorf("story relationship destruction failed: %v", err)
+			log.Errorf("checklist item destruction failed: %v", err)
 			if errors.Is(err, model.InputError{}) {
 				http.Error(w, "something went wrong", http.StatusBadRequest)
 			} else {
@@ -762,124 +782,191 @@ func destroyStoryRelationshipByIDHandle() http.Handler {
 	})
 }
 
-func uploadImageHandle() http.Handler {
+func getSprintsHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
-
-		if err := r.ParseMultipartForm(maxUploadSize); err != nil {
-			log.Errorf("file too large or bad form: %v", err)
-			http.Error(w, "file too large", http.StatusBadRequest)
+		filter, err := model.ParseSprintFilter(env.Log, r.URL.Query())
+		if err != nil {
+			http.Error(w, "invalid filter", http.StatusBadRequest)
 			return
 		}
 
-		file, header, err := r.FormFile("image")
+		sprints, err := model.GetSprints(env.Log, filter)
 		if err != nil {
-			log.Errorf("could not read form file: %v", err)
-			http.Error(w, "missing image field", http.StatusBadRequest)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
 			return
 		}
-		defer file.Close()
 
-		// TODO: file type should be determined by the server, with ext set accordingly.
-		filename := uuid.NewString() + filepath.Ext(header.Filename)
+		js, err := json.Marshal(sprints)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
 
-		// TODO: local file save logic should go to storage package
-		dst, err := os.Create(filepath.Join("../..", uploadsDir, filename))
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+func getCurrentSprintHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sprint, err := model.GetCurrentSprint(env.Log, time.Now())
 		if err != nil {
-			log.Errorf("could not create file: %v", err)
 			http.Error(w, "something went wrong", http.StatusInternalServerError)
 			return
 		}
-		defer dst.Close()
+		if sprint == nil {
+			http.Error(w, "no current sprint", http.StatusNotFound)
+			return
+		}
 
-		hasher := sha256.New()
-		var imageBytes bytes.Buffer
-		written, err := io.Copy(io.MultiWriter(dst, hasher, &imageBytes), file)
+		js, err := json.Marshal(sprint)
 		if err != nil {
-			log.Errorf("could not write file: %v", err)
+			log.Errorf("json.Marshal failed: %v", err)
 			http.Error(w, "something went wrong", http.StatusInternalServerError)
 			return
 		}
 
-		mimeType := header.Header.Get("Content-Type")
-		if mimeType == "" {
-			mimeType = mime.TypeByExtension(filepath.Ext(header.Filename))
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+func getSprintCalendarIssuesHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		issues, err := model.GetSprintCalendarIssues(env.Log)
+		if err != nil {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
 		}
-		if parsed, _, err := mime.ParseMediaType(mimeType); err == nil {
-			mimeType = parsed
+
+		js, err := json.Marshal(issues)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
 		}
-		if mimeType == "" {
-			mimeType = "application/octet-stream"
+
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+func createSprintHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		createReq := model.CreateSprintReq{}
+		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
 		}
 
-		cfg, _, err := image.DecodeConfig(bytes.NewReader(imageBytes.Bytes()))
+		entity, err := model.CreateSprint(env.Log, createReq)
 		if err != nil {
-			log.Errorf("could not decode image config: %v", err)
-			http.Error(w, "invalid image", http.StatusBadRequest)
+			log.Errorf("sprint creation failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
 			return
 		}
 
-		remoteAddr := r.RemoteAddr
-		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
-			remoteAddr = host
+		*r = *r.WithContext(context.WithValue(r.Context(), createEntityIDKey, entity.ID))
+
+		js, err := json.Marshal(entity)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
 		}
 
-		var uploaderIP *string
-		if remoteAddr != "" {
-			uploaderIP = &remoteAddr
+		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	})
+}
+
+func putSprintHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		putReq := model.PutSprintReq{}
+		if err := json.NewDecoder(r.Body).Decode(&putReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
 		}
 
-		var clientFilename *string
-		if header.Filename != "" {
-			clientFilename = &header.Filename
+		entity, err := model.PutSprint(env.Log, putReq)
+		if err != nil {
+			log.Errorf("sprint update failed: %v", err)
+			var conflictErr model.ConflictError
+			if errors.As(err, &conflictErr) {
+				writeConflict(w, conflictErr)
+			} else if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
 		}
 
-		localUploadPath := fmt.Sprintf("/uploads/%s", filename)
-		artifactReqs := []model.UploadArtifact{
-			{
-				ArtifactType: "LOCAL",
-				StorageKey:   filename,
-				PublicURL:    &localUploadPath,
-				PixelWidth:   cfg.Width,
-				PixelHeight:  cfg.Height,
-				ByteSize:     written,
-				MimeType:     mimeType,
-				SHA256Hex:    hex.EncodeToString(hasher.Sum(nil)),
-			},
+		js, err := json.Marshal(entity)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
 		}
 
-		s3Meta, err := env.S3Uploader.Upload(r.Context(), filename, mimeType, imageBytes.Bytes())
-		if err == nil {
-			artifactReqs = append(artifactReqs, model.UploadArtifact{
-				ArtifactType: "S3",
-				StorageKey:   s3Meta.StorageKey,
-				PublicURL:    &s3Meta.PublicURL,
-				PixelWidth:   cfg.Width,
-				PixelHeight:  cfg.Height,
-				ByteSize:     written,
-				MimeType:     mimeType,
-				SHA256Hex:    hex.EncodeToString(hasher.Sum(nil)),
-			})
-			log.Infof("s3 upload succeeded for %s to %s", filename, s3Meta.PublicURL)
-		} else {
-			log.Errorf("s3 upload failed for %s: %v", filename, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	})
+}
+
+func destroySprintHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		destroyReq := model.DestroySprintReq{}
+		if err := json.NewDecoder(r.Body).Decode(&destroyReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
 		}
 
-		err = model.CreateUploadWithArtifacts(log, env.CallerID, model.CreateUploadWithArtifactsReq{
-			UploaderIP:     uploaderIP,
-			ClientFilename: clientFilename,
-			UploadType:     "IMAGE",
-			Artifacts:      artifactReqs,
-		})
+		err := model.DestroySprint(env.Log, destroyReq)
 		if err != nil {
-			log.Errorf("could not persist upload metadata: %v", err)
-			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			log.Errorf("sprint destruction failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
 			return
 		}
+	})
+}
 
-		js, err := json.Marshal(&struct {
-			URL string `json:"url"`
-		}{localUploadPath})
+func rolloverSprintHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rolloverReq := model.RolloverSprintReq{}
+		if err := json.NewDecoder(r.Body).Decode(&rolloverReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		rollover, err := model.RolloverSprint(env.Log, env.Sqids, rolloverReq, sprintDuration)
+		if err != nil {
+			log.Errorf("sprint rollover failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		if rollover.CreatedNextSprint && !rollover.DryRun {
+			*r = *r.WithContext(context.WithValue(r.Context(), createEntityIDKey, rollover.NextSprint.ID))
+		}
+
+		js, err := json.Marshal(rollover)
 		if err != nil {
 			log.Errorf("json.Marshal failed: %v", err)
 			http.Error(w, "something went wrong", http.StatusInternalServerError)
@@ -891,45 +978,44 @@ func uploadImageHandle() http.Handler {
 	})
 }
 
-func getBucketTagAssignmentsHandle() http.Handler {
+func getStoriesHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		assignments, err := model.GetBucketTagAssignments(env.Log)
+		filter, err := model.ParseStoryFilter(env.Log, r.URL.Query())
+		if err != nil {
+			http.Error(w, "invalid filter", http.StatusBadRequest)
+			return
+		}
+
+		stories, err := model.GetStories(env.Log, filter)
 		if err != nil {
 			http.Error(w, "something went wrong", http.StatusInternalServerError)
 			return
 		}
 
-		js, err := json.Marshal(assignments)
+		js, err := json.Marshal(stories)
 		if err != nil {
 			log.Errorf("json.Marshal failed: %v", err)
 			http.Error(w, "something went wrong", http.StatusInternalServerError)
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
-
-		w.Header().Set("Content-Type", "application/json")
-		w.Write(js)
+		writeJSONWithETag(w, r, js)
 	})
 }
 
-func createBucketTagAssignmentHandle() http.Handler {
+func createStoryHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		createReq := model.CreateBucketTagAssignmentReq{}
+		createReq := model.CreateStoryReq{}
 		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
 			log.Errorf("unable to decode json: %v", err)
 			http.Error(w, "something went wrong", http.StatusBadRequest)
 			return
 		}
 
-		entity, err := model.CreateBucketTagAssignment(env.Log, createReq)
+		entity, err := model.CreateStory(env.Log, env.Sqids, createReq)
 		if err != nil {
-			log.Errorf("bucket tag assignment creation failed: %v", err)
-			if errors.Is(err, model.InputError{}) {
-				http.Error(w, "something went wrong", http.StatusBadRequest)
-			} else {
-				http.Error(w, "something went wrong", http.StatusInternalServerError)
-			}
+			log.Errorf("story creation failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
 			return
 		}
 
@@ -949,18 +1035,18 @@ func createBucketTagAssignmentHandle() http.Handler {
 	})
 }
 
-func destroyBucketTagAssignmentByIDHandle() http.Handler {
+func destroyStoryHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		destroyReq := model.DestroyBucketTagAssignmentByIDReq{}
+		destroyReq := model.DestroyStoryReq{}
 		if err := json.NewDecoder(r.Body).Decode(&destroyReq); err != nil {
 			log.Errorf("unable to decode json: %v", err)
 			http.Error(w, "something went wrong", http.StatusBadRequest)
 			return
 		}
 
-		err := model.DestroyBucketTagAssignmentByID(env.Log, destroyReq)
+		err := model.DestroyStory(env.Log, destroyReq)
 		if err != nil {
-			log.Errorf("bucket tag assignment destruction failed: %v", err)
+			log.Errorf("story destruction failed: %v", err)
 			if errors.Is(err, model.InputError{}) {
 				http.Error(w, "something went wrong", http.StatusBadRequest)
 			} else {
@@ -971,40 +1057,73 @@ func destroyBucketTagAssignmentByIDHandle() http.Handler {
 	})
 }
 
-func getBucketsHandle() http.Handler {
+// TAGS
+func getTagsHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		buckets, err := model.GetBuckets(env.Log)
+		// the tree is never paginated
+		var tags interface{}
+		var err error
+		switch r.URL.Query().Get("format") {
+		case "", "flat":
+			var filter model.TagFilter
+			if filter, err = model.ParseTagFilter(env.Log, r.URL.Query()); err != nil {
+				http.Error(w, "invalid filter", http.StatusBadRequest)
+				return
+			}
+			tags, err = model.GetTags(env.Log, filter)
+		case "tree":
+			tags, err = model.GetTagTree(env.Log)
+		default:
+			http.Error(w, "format must be flat or tree", http.StatusBadRequest)
+			return
+		}
 		if err != nil {
 			http.Error(w, "something went wrong", http.StatusInternalServerError)
 			return
 		}
 
-		js, err := json.Marshal(buckets)
+		js, err := json.Marshal(tags)
 		if err != nil {
 			log.Errorf("json.Marshal failed: %v", err)
 			http.Error(w, "something went wrong", http.StatusInternalServerError)
 			return
 		}
 
-		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
+		writeJSONWithETag(w, r, js)
+	})
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.Write(js)
+func getTagAssignmentsHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tagAssignments, err := model.GetTagAssignments(env.Log)
+		if err != nil {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		js, err := json.Marshal(tagAssignments)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSONWithETag(w, r, js)
 	})
 }
 
-func createBucketHandle() http.Handler {
+func createTagAssignmentHandle() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		createReq := model.CreateBucketReq{}
+		createReq := model.CreateTagAssignmentReq{}
 		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
 			log.Errorf("unable to decode json: %v", err)
 			http.Error(w, "something went wrong", http.StatusBadRequest)
 			return
 		}
 
-		entity, err := model.CreateBucket(env.Log, env.Sqids, createReq)
+		entity, err := model.CreateTagAssignment(env.Log, createReq)
 		if err != nil {
-			log.Errorf("bucket creation failed: %v", err)
+			log.Errorf("tag assignment creation failed: %v", err)
 			if errors.Is(err, model.InputError{}) {
 				http.Error(w, "something went wrong", http.StatusBadRequest)
 			} else {
@@ -1029,6 +1148,1132 @@ func createBucketHandle() http.Handler {
 	})
 }
 
+func destroyTagAssignmentHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		destroyReq := model.DestroyTagAssignmentReq{}
+		if err := json.NewDecoder(r.Body).Decode(&destroyReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		err := model.DestroyTagAssignment(env.Log, destroyReq)
+		if err != nil {
+			log.Errorf("tag assignment destruction failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+	})
+}
+
+func destroyTagAssignmentByIDHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		destroyReq := model.DestroyTagAssignmentByIDReq{}
+		if err := json.NewDecoder(r.Body).Decode(&destroyReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		err := model.DestroyTagAssignmentByID(env.Log, destroyReq)
+		if err != nil {
+			log.Errorf("tag assignment destruction failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+	})
+}
+
+func createTagHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		createReq := model.CreateTagReq{}
+		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		entity, err := model.CreateTag(env.Log, createReq)
+		if err != nil {
+			log.Errorf("tag creation failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		*r = *r.WithContext(context.WithValue(r.Context(), createEntityIDKey, entity.ID))
+
+		js, err := json.Marshal(entity)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	})
+}
+
+func putTagHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		putReq := model.PutTagReq{}
+		if err := json.NewDecoder(r.Body).Decode(&putReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		entity, err := model.PutTag(env.Log, putReq)
+		if err != nil {
+			log.Errorf("tag update failed: %v", err)
+			var conflictErr model.ConflictError
+			if errors.As(err, &conflictErr) {
+				writeConflict(w, conflictErr)
+			} else if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		js, err := json.Marshal(entity)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	})
+}
+
+func destroyTagHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		destroyReq := model.DestroyTagReq{}
+		if err := json.NewDecoder(r.Body).Decode(&destroyReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		err := model.DestroyTag(env.Log, destroyReq)
+		if err != nil {
+			log.Errorf("tag destruction failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+	})
+}
+
+func getStoryRelationshipsHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		StoryRelationships, err := model.GetStoryRelationships(env.Log)
+		if err != nil {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		js, err := json.Marshal(StoryRelationships)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+func getStoryGraphHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		storyID := r.URL.Query().Get("id")
+
+		var story *model.Story
+		var err error
+		if looksLikeUUIDv4(storyID) {
+			story, err = model.GetStoryByID(env.Log, storyID)
+		} else {
+			story, err = model.GetStoryBySQID(env.Log, storyID)
+		}
+		if err != nil {
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		graph, err := model.GetStoryGraph(env.Log, story.ID)
+		if err != nil {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		js, err := json.Marshal(graph)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+func createStoryRelationshipHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		createReq := model.CreateStoryRelationshipReq{}
+		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		entity, err := model.CreateStoryRelationship(env.Log, createReq)
+		if err != nil {
+			log.Errorf("story relationship creation failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		*r = *r.WithContext(context.WithValue(r.Context(), createEntityIDKey, entity.ID))
+
+		js, err := json.Marshal(entity)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	})
+}
+
+func destroyStoryRelationshipByIDHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		destroyReq := model.DestroyStoryRelationshipByIDReq{}
+		if err := json.NewDecoder(r.Body).Decode(&destroyReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		err := model.DestroyStoryRelationshipByID(env.Log, destroyReq)
+		if err != nil {
+			log.Errorf("story relationship destruction failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+	})
+}
+
+func getTaskRelationshipsHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		taskRelationships, err := model.GetTaskRelationships(env.Log)
+		if err != nil {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		js, err := json.Marshal(taskRelationships)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+func createTaskRelationshipHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		createReq := model.CreateTaskRelationshipReq{}
+		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		entity, err := model.CreateTaskRelationship(env.Log, createReq)
+		if err != nil {
+			log.Errorf("task relationship creation failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		*r = *r.WithContext(context.WithValue(r.Context(), createEntityIDKey, entity.ID))
+
+		js, err := json.Marshal(entity)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	})
+}
+
+func destroyTaskRelationshipByIDHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		destroyReq := model.DestroyTaskRelationshipByIDReq{}
+		if err := json.NewDecoder(r.Body).Decode(&destroyReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		err := model.DestroyTaskRelationshipByID(env.Log, destroyReq)
+		if err != nil {
+			log.Errorf("task relationship destruction failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+	})
+}
+
+func uploadImageHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+
+		if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+			log.Errorf("file too large or bad form: %v", err)
+			http.Error(w, "file too large", http.StatusBadRequest)
+			return
+		}
+
+		file, header, err := r.FormFile("image")
+		if err != nil {
+			log.Errorf("could not read form file: %v", err)
+			http.Error(w, "missing image field", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		// TODO: file type should be determined by the server, with ext set accordingly.
+		filename := uuid.NewString() + filepath.Ext(header.Filename)
+
+		// TODO: local file save logic should go to storage package
+		dst, err := os.Create(filepath.Join("../..", uploadsDir, filename))
+		if err != nil {
+			log.Errorf("could not create file: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+		defer dst.Close()
+
+		hasher := sha256.New()
+		var imageBytes bytes.Buffer
+		written, err := io.Copy(io.MultiWriter(dst, hasher, &imageBytes), file)
+		if err != nil {
+			log.Errorf("could not write file: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		mimeType := header.Header.Get("Content-Type")
+		if mimeType == "" {
+			mimeType = mime.TypeByExtension(filepath.Ext(header.Filename))
+		}
+		if parsed, _, err := mime.ParseMediaType(mimeType); err == nil {
+			mimeType = parsed
+		}
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(imageBytes.Bytes()))
+		if err != nil {
+			log.Errorf("could not decode image config: %v", err)
+			http.Error(w, "invalid image", http.StatusBadRequest)
+			return
+		}
+
+		remoteAddr := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			remoteAddr = host
+		}
+
+		var uploaderIP *string
+		if remoteAddr != "" {
+			uploaderIP = &remoteAddr
+		}
+
+		var clientFilename *string
+		if header.Filename != "" {
+			clientFilename = &header.Filename
+		}
+
+		localUploadPath := fmt.Sprintf("/uploads/%s", filename)
+		artifactReqs := []model.UploadArtifact{
+			{
+				ArtifactType: "LOCAL",
+				StorageKey:   filename,
+				PublicURL:    &localUploadPath,
+				PixelWidth:   cfg.Width,
+				PixelHeight:  cfg.Height,
+				ByteSize:     written,
+				MimeType:     mimeType,
+				SHA256Hex:    hex.EncodeToString(hasher.Sum(nil)),
+			},
+		}
+
+		s3Meta, err := env.S3Uploader.Upload(r.Context(), filename, mimeType, imageBytes.Bytes())
+		if err == nil {
+			artifactReqs = append(artifactReqs, model.UploadArtifact{
+				ArtifactType: "S3",
+				StorageKey:   s3Meta.StorageKey,
+				PublicURL:    &s3Meta.PublicURL,
+				PixelWidth:   cfg.Width,
+				PixelHeight:  cfg.Height,
+				ByteSize:     written,
+				MimeType:     mimeType,
+				SHA256Hex:    hex.EncodeToString(hasher.Sum(nil)),
+			})
+			log.Infof("s3 upload succeeded for %s to %s", filename, s3Meta.PublicURL)
+		} else {
+			log.Errorf("s3 upload failed for %s: %v", filename, err)
+		}
+
+		err = model.CreateUploadWithArtifacts(log, env.CallerID, model.CreateUploadWithArtifactsReq{
+			UploaderIP:     uploaderIP,
+			ClientFilename: clientFilename,
+			UploadType:     "IMAGE",
+			Artifacts:      artifactReqs,
+		})
+		if err != nil {
+			log.Errorf("could not persist upload metadata: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		js, err := json.Marshal(&struct {
+			URL string `json:"url"`
+		}{localUploadPath})
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	})
+}
+
+func getBucketTagAssignmentsHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assignments, err := model.GetBucketTagAssignments(env.Log)
+		if err != nil {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		js, err := json.Marshal(assignments)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+func createBucketTagAssignmentHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		createReq := model.CreateBucketTagAssignmentReq{}
+		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		entity, err := model.CreateBucketTagAssignment(env.Log, createReq)
+		if err != nil {
+			log.Errorf("bucket tag assignment creation failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		*r = *r.WithContext(context.WithValue(r.Context(), createEntityIDKey, entity.ID))
+
+		js, err := json.Marshal(entity)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	})
+}
+
+func destroyBucketTagAssignmentByIDHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		destroyReq := model.DestroyBucketTagAssignmentByIDReq{}
+		if err := json.NewDecoder(r.Body).Decode(&destroyReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		err := model.DestroyBucketTagAssignmentByID(env.Log, destroyReq)
+		if err != nil {
+			log.Errorf("bucket tag assignment destruction failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+	})
+}
+
+func getTaskTagAssignmentsHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assignments, err := model.GetTaskTagAssignments(env.Log)
+		if err != nil {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		js, err := json.Marshal(assignments)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+func createTaskTagAssignmentHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		createReq := model.CreateTaskTagAssignmentReq{}
+		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		entity, err := model.CreateTaskTagAssignment(env.Log, createReq)
+		if err != nil {
+			log.Errorf("task tag assignment creation failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		*r = *r.WithContext(context.WithValue(r.Context(), createEntityIDKey, entity.ID))
+
+		js, err := json.Marshal(entity)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	})
+}
+
+func destroyTaskTagAssignmentByIDHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		destroyReq := model.DestroyTaskTagAssignmentByIDReq{}
+		if err := json.NewDecoder(r.Body).Decode(&destroyReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		err := model.DestroyTaskTagAssignmentByID(env.Log, destroyReq)
+		if err != nil {
+			log.Errorf("task tag assignment destruction failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+	})
+}
+
+// getTaskTagsHandle returns the tags of every task, or of the task given by ?task_id.
+// ?inherit=true includes the tags of each task's story and bucket.
+func getTaskTagsHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		taskID := r.URL.Query().Get("task_id")
+		if taskID != "" {
+			if _, err := uuid.Parse(taskID); err != nil {
+				http.Error(w, "invalid task_id", http.StatusBadRequest)
+				return
+			}
+		}
+		inherit := r.URL.Query().Get("inherit") == "true"
+
+		taskTags, err := model.GetTaskTags(env.Log, taskID, inherit)
+		if err != nil {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		js, err := json.Marshal(taskTags)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+func getBucketByIDHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bucketID := r.URL.Query().Get("id")
+
+		var bucket *model.Bucket
+		var err error
+		if looksLikeUUIDv4(bucketID) {
+			bucket, err = model.GetBucketByID(env.Log, bucketID)
+		} else {
+			bucket, err = model.GetBucketBySQID(env.Log, bucketID)
+		}
+		if err != nil {
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		tasks, err := model.GetTasksByBucketID(env.Log, bucket.ID)
+		if err != nil {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		js, err := json.Marshal(model.BucketWithTasks{Bucket: *bucket, Tasks: tasks})
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+func getBucketsHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tagID := r.URL.Query().Get("tag_id")
+		if tagID != "" {
+			if _, err := uuid.Parse(tagID); err != nil {
+				http.Error(w, "invalid tag_id", http.StatusBadRequest)
+				return
+			}
+		}
+
+		buckets, err := model.GetBuckets(env.Log, tagID)
+		if err != nil {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		js, err := json.Marshal(buckets)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+func createBucketHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		createReq := model.CreateBucketReq{}
+		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		entity, err := model.CreateBucket(env.Log, env.Sqids, createReq)
+		if err != nil {
+			log.Errorf("bucket creation failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		*r = *r.WithContext(context.WithValue(r.Context(), createEntityIDKey, entity.ID))
+
+		js, err := json.Marshal(entity)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	})
+}
+
+func putBucketHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		putReq := model.PutBucketReq{}
+		if err := json.NewDecoder(r.Body).Decode(&putReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		entity, err := model.PutBucket(env.Log, putReq)
+		if err != nil {
+			log.Errorf("bucket update failed: %v", err)
+			var conflictErr model.ConflictError
+			if errors.As(err, &conflictErr) {
+				writeConflict(w, conflictErr)
+			} else if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		js, err := json.Marshal(entity)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	})
+}
+
+func destroyBucketHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		destroyReq := model.DestroyBucketReq{}
+		if err := json.NewDecoder(r.Body).Decode(&destroyReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		err := model.DestroyBucket(env.Log, destroyReq)
+		if err != nil {
+			log.Errorf("bucket destruction failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+	})
+}
+
+func getTrashHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trashItems, err := model.GetTrash(env.Log)
+		if err != nil {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		js, err := json.Marshal(trashItems)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+func restoreEntityHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		restoreReq := model.RestoreEntityReq{}
+		if err := json.NewDecoder(r.Body).Decode(&restoreReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		err := model.RestoreEntity(env.Log, restoreReq)
+		if err != nil {
+			log.Errorf("entity restore failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+	})
+}
+
+// searchHandle runs a full-text search given by ?q, optionally narrowed
+// by ?kind, ?status and ?sprint_id
+func searchHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter, err := model.ParseSearchFilter(env.Log, r.URL.Query())
+		if err != nil {
+			http.Error(w, "invalid search", http.StatusBadRequest)
+			return
+		}
+
+		results, err := model.Search(env.Log, filter)
+		if err != nil {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		js, err := json.Marshal(results)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+// SAVED VIEWS
+func getSavedViewsHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		views, err := model.GetSavedViews(env.Log)
+		if err != nil {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		js, err := json.Marshal(views)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+func createSavedViewHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		createReq := model.CreateSavedViewReq{}
+		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		entity, err := model.CreateSavedView(env.Log, createReq)
+		if err != nil {
+			log.Errorf("saved view creation failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		*r = *r.WithContext(context.WithValue(r.Context(), createEntityIDKey, entity.ID))
+
+		js, err := json.Marshal(entity)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	})
+}
+
+func putSavedViewHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		putReq := model.PutSavedViewReq{}
+		if err := json.NewDecoder(r.Body).Decode(&putReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		entity, err := model.PutSavedView(env.Log, putReq)
+		if err != nil {
+			log.Errorf("saved view update failed: %v", err)
+			var conflictErr model.ConflictError
+			if errors.As(err, &conflictErr) {
+				writeConflict(w, conflictErr)
+			} else if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		js, err := json.Marshal(entity)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	})
+}
+
+func destroySavedViewHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		destroyReq := model.DestroySavedViewReq{}
+		if err := json.NewDecoder(r.Body).Decode(&destroyReq); err != nil {
+			log.Errorf("unable to decode json: %v", err)
+			http.Error(w, "something went wrong", http.StatusBadRequest)
+			return
+		}
+
+		err := model.DestroySavedView(env.Log, destroyReq)
+		if err != nil {
+			log.Errorf("saved view destruction failed: %v", err)
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+	})
+}
+
+// getViewResultsHandle runs the saved view given by ?id.
+// ?cursor and ?limit page through the results as for get_tasks.
+func getViewResultsHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		viewID := r.URL.Query().Get("id")
+		if _, err := uuid.Parse(viewID); err != nil {
+			http.Error(w, "invalid id", http.StatusBadRequest)
+			return
+		}
+
+		results, err := model.GetViewResults(env.Log, viewID, r.URL.Query())
+		if err != nil {
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		js, err := json.Marshal(results)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+func getEntityHistoryHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		kind := r.URL.Query().Get("kind")
+		sqid := r.URL.Query().Get("id")
+
+		revisions, err := model.GetEntityHistory(env.Log, kind, sqid)
+		if err != nil {
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		js, err := json.Marshal(revisions)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+func getSprintFlowMetricsHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sprintID := r.URL.Query().Get("sprint_id")
+
+		sprintFlowMetrics, err := model.GetSprintFlowMetrics(env.Log, sprintID)
+		if err != nil {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		js, err := json.Marshal(sprintFlowMetrics)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+func getSprintReportHandle() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sprintID := r.URL.Query().Get("sprint_id")
+
+		velocitySprints := model.DefaultVelocitySprints
+		if v := r.URL.Query().Get("velocity_sprints"); v != "" {
+			var err error
+			velocitySprints, err = strconv.Atoi(v)
+			if err != nil {
+				log.Errorf("invalid velocity_sprints: %s", v)
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+				return
+			}
+		}
+
+		report, err := model.GetSprintReport(env.Log, sprintID, velocitySprints)
+		if err != nil {
+			if errors.Is(err, model.InputError{}) {
+				http.Error(w, "something went wrong", http.StatusBadRequest)
+			} else {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		js, err := json.Marshal(report)
+		if err != nil {
+			log.Errorf("json.Marshal failed: %v", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSONWithETag(w, r, js)
+	})
+}
+
+// writeConflict responds 409 with the entity as it currently is,
+// so the client can reconcile its stale copy
+func writeConflict(w http.ResponseWriter, conflictErr model.ConflictError) {
+	js, err := json.Marshal(conflictErr.Current)
+	if err != nil {
+		log.Errorf("json.Marshal failed: %v", err)
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	w.Write(js)
+}
+
+// writeJSONWithETag writes js with a strong ETag, or a bodyless 304
+// if the client's If-None-Match already matches
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, js []byte) {
+	etag := cache.ETag(js)
+	w.Header().Set("ETag", etag)
+
+	if cache.ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, 0))
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	*r = *r.WithContext(context.WithValue(r.Context(), getRequestBytesKey, len(js)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
 func looksLikeUUIDv4(id string) bool {
 	return len(id) == 36 && strings.Count(id, "-") == 4 && id[14] == '4'
 }
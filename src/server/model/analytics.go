@@ -0,0 +1,184 @@
+package model
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/bschlaman/todo-app/database"
+)
+
+// the statuses which start and finish work on a task or story
+const (
+	statusDoing = "DOING"
+	statusDone  = "DONE"
+)
+
+// statusTransition is a status change read from entity_history
+type statusTransition struct {
+	at     time.Time
+	status string
+}
+
+// GetSprintFlowMetrics returns lead time, cycle time and time in status
+// percentiles for the tasks and stories of each sprint, derived from the
+// status changes recorded in entity_history.  A task belongs to the sprint
+// of its story; tasks in buckets are not counted.
+// If sprintID is blank, every sprint is reported.
+func GetSprintFlowMetrics(log *logger.BLogger, sprintID string) ([]SprintFlowMetrics, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT
+				sp.id,
+				sp.title,
+				h.entity_kind,
+				h.entity_id,
+				e.created_at,
+				e.status,
+				h.created_at,
+				h.changes->'status'->>'new'
+				FROM entity_history h
+				JOIN (
+					SELECT 'task' AS kind, t.id::text AS id, t.created_at, t.status::text AS status, s.sprint_id
+						FROM tasks t
+						JOIN stories s ON t.story_id = s.id
+						WHERE t.deleted_at IS NULL
+					UNION ALL
+					SELECT 'story', s.id::text, s.created_at, s.status::text, s.sprint_id
+						FROM stories s
+						WHERE s.deleted_at IS NULL
+				) e ON h.entity_kind = e.kind AND h.entity_id = e.id
+				JOIN sprints sp ON e.sprint_id = sp.id
+				WHERE h.changes ? 'status'
+				AND ($1 = '' OR sp.id::text = $1)
+				ORDER BY sp.start_date, h.entity_kind, h.entity_id, h.id`,
+		sprintID,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	type entityKey struct{ kind, id string }
+	type entityTransitions struct {
+		createdAt     time.Time
+		currentStatus string
+		transitions   []statusTransition
+	}
+
+	var sprintIDs []string
+	sprintTitles := map[string]string{}
+	entitiesBySprint := map[string]map[entityKey]*entityTransitions{}
+	for rows.Next() {
+		var spID, spTitle, kind, entityID, currentStatus, newStatus string
+		var createdAt, at time.Time
+		rows.Scan(&spID, &spTitle, &kind, &entityID, &createdAt, &currentStatus, &at, &newStatus)
+
+		if _, ok := entitiesBySprint[spID]; !ok {
+			sprintIDs = append(sprintIDs, spID)
+			sprintTitles[spID] = spTitle
+			entitiesBySprint[spID] = map[entityKey]*entityTransitions{}
+		}
+		key := entityKey{kind, entityID}
+		e, ok := entitiesBySprint[spID][key]
+		if !ok {
+			e = &entityTransitions{createdAt: createdAt, currentStatus: currentStatus}
+			entitiesBySprint[spID][key] = e
+		}
+		e.transitions = append(e.transitions, statusTransition{at, newStatus})
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	var sprintFlowMetrics = []SprintFlowMetrics{}
+	for _, spID := range sprintIDs {
+		durations := map[string]*flowDurations{
+			HistoryKindTask:  newFlowDurations(taskStatuses),
+			HistoryKindStory: newFlowDurations(storyStatuses),
+		}
+		for key, e := range entitiesBySprint[spID] {
+			durations[key.kind].add(e.createdAt, e.currentStatus, e.transitions)
+		}
+		sprintFlowMetrics = append(sprintFlowMetrics, SprintFlowMetrics{
+			spID,
+			sprintTitles[spID],
+			durations[HistoryKindTask].metrics(),
+			durations[HistoryKindStory].metrics(),
+		})
+	}
+
+	return sprintFlowMetrics, nil
+}
+
+// flowDurations collects the durations, in seconds, that make up FlowMetrics
+type flowDurations struct {
+	leadTimes    []float64
+	cycleTimes   []float64
+	timeInStatus map[string][]float64
+}
+
+func newFlowDurations(statuses map[string]bool) *flowDurations {
+	fd := &flowDurations{timeInStatus: map[string][]float64{}}
+	for status := range statuses {
+		fd.timeInStatus[status] = nil
+	}
+	return fd
+}
+
+// add records the durations for one entity given its ordered status transitions.
+// Time in a status is only counted once the entity has left that status.
+func (fd *flowDurations) add(createdAt time.Time, currentStatus string, transitions []statusTransition) {
+	var firstDoing, lastDone *time.Time
+	for i, t := range transitions {
+		if i+1 < len(transitions) {
+			fd.timeInStatus[t.status] = append(fd.timeInStatus[t.status], transitions[i+1].at.Sub(t.at).Seconds())
+		}
+		if t.status == statusDoing && firstDoing == nil {
+			firstDoing = &transitions[i].at
+		}
+		if t.status == statusDone {
+			lastDone = &transitions[i].at
+		}
+	}
+
+	if currentStatus != statusDone || lastDone == nil {
+		return
+	}
+	fd.leadTimes = append(fd.leadTimes, lastDone.Sub(createdAt).Seconds())
+	if firstDoing != nil && firstDoing.Before(*lastDone) {
+		fd.cycleTimes = append(fd.cycleTimes, lastDone.Sub(*firstDoing).Seconds())
+	}
+}
+
+func (fd *flowDurations) metrics() FlowMetrics {
+	timeInStatus := map[string]DurationPercentiles{}
+	for status, durations := range fd.timeInStatus {
+		timeInStatus[status] = percentiles(durations)
+	}
+	return FlowMetrics{percentiles(fd.leadTimes), percentiles(fd.cycleTimes), timeInStatus}
+}
+
+// percentiles computes nearest-rank percentiles; an empty set is all zeros
+func percentiles(durations []float64) DurationPercentiles {
+	if len(durations) == 0 {
+		return DurationPercentiles{}
+	}
+	sorted := append([]float64(nil), durations...)
+	sort.Float64s(sorted)
+	rank := func(p float64) float64 {
+		i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		return sorted[max(i, 0)]
+	}
+	return DurationPercentiles{len(sorted), rank(50), rank(75), rank(90), sorted[len(sorted)-1]}
+}
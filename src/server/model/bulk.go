@@ -0,0 +1,415 @@
+package model
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/bschlaman/todo-app/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// MaxBulkItems bounds the number of ids in one bulk update
+const MaxBulkItems = 500
+
+// Kinds of entities a bulk update applies to
+const (
+	BulkKindTask  = "task"
+	BulkKindStory = "story"
+)
+
+// BulkUpdate applies one change set to every task or story in updateReq.IDs,
+// in a single transaction.  Nothing is written unless every id is a live
+// entity of the given kind; otherwise the result is not Applied and the
+// items which failed carry an Error.  Entities are locked while they are
+// updated, so unlike put_task no updated_at is needed.
+func BulkUpdate(log *logger.BLogger, updateReq BulkUpdateReq) (*BulkUpdateResult, error) {
+	if err := checkBulkUpdateReq(log, updateReq); err != nil {
+		return nil, err
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	if updateReq.StoryID.Set && updateReq.StoryID.Value != nil {
+		if err := validateTaskParent(log, tx, updateReq.StoryID.Value, nil); err != nil {
+			return nil, err
+		}
+	}
+	if updateReq.BucketID.Set && updateReq.BucketID.Value != nil {
+		if err := validateTaskParent(log, tx, nil, updateReq.BucketID.Value); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkTagsExist(log, tx, append(updateReq.AddTagIDs, updateReq.RemoveTagIDs...)); err != nil {
+		return nil, err
+	}
+
+	result := &BulkUpdateResult{Applied: true, Items: []BulkItemResult{}}
+	switch updateReq.EntityKind {
+	case BulkKindTask:
+		err = bulkUpdateTasks(log, tx, updateReq, result)
+	case BulkKindStory:
+		err = bulkUpdateStories(log, tx, updateReq, result)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !result.Applied {
+		return result, nil
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// checkBulkUpdateReq validates what can be checked without the database
+func checkBulkUpdateReq(log *logger.BLogger, updateReq BulkUpdateReq) error {
+	if len(updateReq.IDs) == 0 || len(updateReq.IDs) > MaxBulkItems {
+		log.Errorf("bulkUpdate: between 1 and %d ids are required, got %d", MaxBulkItems, len(updateReq.IDs))
+		return InputError{}
+	}
+	seen := make(map[string]bool)
+	for _, id := range updateReq.IDs {
+		if _, err := uuid.Parse(id); err != nil || seen[id] {
+			log.Errorf("bulkUpdate: invalid or repeated id %q", id)
+			return InputError{}
+		}
+		seen[id] = true
+	}
+
+	var statuses map[string]bool
+	switch updateReq.EntityKind {
+	case BulkKindTask:
+		statuses = taskStatuses
+	case BulkKindStory:
+		statuses = storyStatuses
+		if updateReq.StoryID.Set || updateReq.BucketID.Set {
+			log.Error("bulkUpdate: stories cannot be moved to a story or bucket")
+			return InputError{}
+		}
+	default:
+		log.Errorf("bulkUpdate: invalid entity kind %q", updateReq.EntityKind)
+		return InputError{}
+	}
+	if updateReq.Status != nil && !statuses[*updateReq.Status] {
+		log.Errorf("bulkUpdate: invalid status %s", *updateReq.Status)
+		return InputError{}
+	}
+	if updateReq.StoryID.Set && updateReq.StoryID.Value != nil &&
+		updateReq.BucketID.Set && updateReq.BucketID.Value != nil {
+		log.Error("bulkUpdate: tasks cannot be moved to both a story and a bucket")
+		return InputError{}
+	}
+
+	removing := make(map[string]bool)
+	for _, tagID := range updateReq.RemoveTagIDs {
+		removing[tagID] = true
+	}
+	for _, tagID := range updateReq.AddTagIDs {
+		if removing[tagID] {
+			log.Errorf("bulkUpdate: tag %s is both added and removed", tagID)
+			return InputError{}
+		}
+	}
+
+	if updateReq.Status == nil && !updateReq.StoryID.Set && !updateReq.BucketID.Set &&
+		len(updateReq.AddTagIDs) == 0 && len(updateReq.RemoveTagIDs) == 0 {
+		log.Error("bulkUpdate: no changes given")
+		return InputError{}
+	}
+	return nil
+}
+
+// checkTagsExist returns an InputError unless every id is a tag
+func checkTagsExist(log *logger.BLogger, q queryRower, tagIDs []string) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+	for _, tagID := range tagIDs {
+		if _, err := uuid.Parse(tagID); err != nil {
+			log.Errorf("invalid tag id %q", tagID)
+			return InputError{}
+		}
+	}
+	var missing int
+	err := q.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM unnest($1::uuid[]) AS ids(id)
+			WHERE NOT EXISTS (SELECT 1 FROM tags WHERE tags.id = ids.id)`,
+		tagIDs,
+	).Scan(&missing)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return err
+	}
+	if missing > 0 {
+		log.Errorf("%d of the tags do not exist", missing)
+		return InputError{}
+	}
+	return nil
+}
+
+func bulkUpdateTasks(log *logger.BLogger, tx pgx.Tx, updateReq BulkUpdateReq, result *BulkUpdateResult) error {
+	// rows are locked in id order so that concurrent bulk updates cannot deadlock
+	rows, err := tx.Query(context.Background(),
+		`SELECT
+				id,
+				sqid,
+				created_at,
+				updated_at,
+				title,
+				description,
+				status,
+				story_id,
+				bucket_id,
+				edited,
+				bulk_task
+				FROM tasks
+				WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL
+				ORDER BY id
+				FOR UPDATE`,
+		updateReq.IDs,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return err
+	}
+	tasks := make(map[string]Task)
+	for rows.Next() {
+		var id, sqid, title, desc, status string
+		var storyID, bucketID *string
+		var cAt, uAt time.Time
+		var edited, bulkTask bool
+		rows.Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &storyID, &bucketID, &edited, &bulkTask)
+		tasks[id] = Task{id, sqid, cAt, uAt, title, desc, status, storyID, bucketID, edited, bulkTask, nil, nil}
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return rows.Err()
+	}
+	if !checkBulkItemsFound(updateReq.IDs, len(tasks), func(id string) bool { _, ok := tasks[id]; return ok }, result) {
+		return nil
+	}
+
+	for _, id := range updateReq.IDs {
+		current := tasks[id]
+		putReq := PutTaskReq{
+			current.ID,
+			current.Status,
+			current.Title,
+			current.Description,
+			current.StoryID,
+			current.BucketID,
+			current.UpdatedAt,
+		}
+		if updateReq.Status != nil {
+			putReq.Status = *updateReq.Status
+		}
+		// moving a task into a story takes it out of its bucket, and vice versa
+		if updateReq.StoryID.Set {
+			putReq.StoryID = updateReq.StoryID.Value
+			if putReq.StoryID != nil {
+				putReq.BucketID = nil
+			}
+		}
+		if updateReq.BucketID.Set {
+			putReq.BucketID = updateReq.BucketID.Value
+			if putReq.BucketID != nil {
+				putReq.StoryID = nil
+			}
+		}
+
+		item := BulkItemResult{ID: id, Task: &current}
+		if putReq.Status != current.Status || !sameID(putReq.StoryID, current.StoryID) || !sameID(putReq.BucketID, current.BucketID) {
+			updated, err := updateTask(log, tx, putReq)
+			if err != nil {
+				return err
+			}
+			item.Task = &updated.Task
+			item.Warnings = updated.Warnings
+		}
+		if err := updateEntityTags(log, tx, HistoryKindTask, id, updateReq.AddTagIDs, updateReq.RemoveTagIDs); err != nil {
+			return err
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	return nil
+}
+
+func bulkUpdateStories(log *logger.BLogger, tx pgx.Tx, updateReq BulkUpdateReq, result *BulkUpdateResult) error {
+	// rows are locked in id order so that concurrent bulk updates cannot deadlock
+	rows, err := tx.Query(context.Background(),
+		`SELECT
+				id,
+				sqid,
+				created_at,
+				updated_at,
+				title,
+				description,
+				status,
+				sprint_id,
+				edited
+				FROM stories
+				WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL
+				ORDER BY id
+				FOR UPDATE`,
+		updateReq.IDs,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return err
+	}
+	stories := make(map[string]Story)
+	for rows.Next() {
+		var id, sqid, title, desc, status, sprintID string
+		var cAt, uAt time.Time
+		var edited bool
+		rows.Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &sprintID, &edited)
+		stories[id] = Story{id, sqid, cAt, uAt, title, desc, status, sprintID, edited}
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return rows.Err()
+	}
+	if !checkBulkItemsFound(updateReq.IDs, len(stories), func(id string) bool { _, ok := stories[id]; return ok }, result) {
+		return nil
+	}
+
+	for _, id := range updateReq.IDs {
+		current := stories[id]
+		item := BulkItemResult{ID: id, Story: &current}
+		if updateReq.Status != nil && *updateReq.Status != current.Status {
+			updated, err := updateStory(log, tx, PutStoryReq{
+				current.ID,
+				*updateReq.Status,
+				current.Title,
+				current.Description,
+				current.SprintID,
+				current.UpdatedAt,
+			})
+			if err != nil {
+				return err
+			}
+			item.Story = updated
+		}
+		if err := updateEntityTags(log, tx, HistoryKindStory, id, updateReq.AddTagIDs, updateReq.RemoveTagIDs); err != nil {
+			return err
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	return nil
+}
+
+// checkBulkItemsFound fills result with an error for each id which was not
+// found, and reports whether all of them were
+func checkBulkItemsFound(ids []string, found int, exists func(string) bool, result *BulkUpdateResult) bool {
+	if found == len(ids) {
+		return true
+	}
+	result.Applied = false
+	for _, id := range ids {
+		item := BulkItemResult{ID: id}
+		if !exists(id) {
+			item.Error = "not found"
+		}
+		result.Items = append(result.Items, item)
+	}
+	return false
+}
+
+// updateEntityTags adds and removes tag assignments of a task or story,
+// recording the change of its tags in the history
+func updateEntityTags(log *logger.BLogger, tx pgx.Tx, kind, entityID string, addTagIDs, removeTagIDs []string) error {
+	if len(addTagIDs) == 0 && len(removeTagIDs) == 0 {
+		return nil
+	}
+	table, column := "task_tag_assignments", "task_id"
+	if kind == HistoryKindStory {
+		table, column = "tag_assignments", "story_id"
+	}
+
+	before, err := entityTagIDs(log, tx, table, column, entityID)
+	if err != nil {
+		return err
+	}
+	if len(addTagIDs) > 0 {
+		_, err = tx.Exec(context.Background(),
+			`INSERT INTO `+table+` (tag_id, `+column+`)
+				SELECT unnest($1::uuid[]), $2
+				ON CONFLICT DO NOTHING`,
+			addTagIDs,
+			entityID,
+		)
+		if err != nil {
+			log.Errorf("failed to add tags: %v", err)
+			return err
+		}
+	}
+	if len(removeTagIDs) > 0 {
+		_, err = tx.Exec(context.Background(),
+			`DELETE FROM `+table+` WHERE `+column+` = $1 AND tag_id = ANY($2::uuid[])`,
+			entityID,
+			removeTagIDs,
+		)
+		if err != nil {
+			log.Errorf("failed to remove tags: %v", err)
+			return err
+		}
+	}
+	after, err := entityTagIDs(log, tx, table, column, entityID)
+	if err != nil {
+		return err
+	}
+
+	return recordHistory(log, tx, kind, entityID, HistoryActionUpdate, diffFields(
+		map[string]*string{"tags": before},
+		map[string]*string{"tags": after},
+	))
+}
+
+// entityTagIDs returns the sorted, comma separated ids of an entity's tags,
+// or nil if it has none
+func entityTagIDs(log *logger.BLogger, tx pgx.Tx, table, column, entityID string) (*string, error) {
+	tagIDs, err := queryStrings(log, tx,
+		`SELECT tag_id::text FROM `+table+` WHERE `+column+` = $1 ORDER BY tag_id`,
+		entityID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(tagIDs) == 0 {
+		return nil, nil
+	}
+	joined := strings.Join(tagIDs, ",")
+	return &joined, nil
+}
+
+// sameID reports whether two optional ids are equal
+func sameID(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
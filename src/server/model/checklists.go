@@ -0,0 +1,408 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/bschlaman/todo-app/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// checklistProgressSQL selects the done and total counts of a task's
+// checklist items.  alias qualifies the task's id column, e.g. "t."
+func checklistProgressSQL(alias string) string {
+	return `(SELECT COUNT(*) FROM checklist_items ci WHERE ci.task_id = ` + alias + `id AND ci.done) AS checklist_done,
+				(SELECT COUNT(*) FROM checklist_items ci WHERE ci.task_id = ` + alias + `id) AS checklist_total`
+}
+
+// lockChecklistTask locks a task's row so that its checklist positions can be
+// changed without racing other writers.  Tasks in the trash cannot be edited.
+func lockChecklistTask(log *logger.BLogger, tx pgx.Tx, taskID string) error {
+	var id string
+	err := tx.QueryRow(context.Background(),
+		`SELECT id FROM tasks WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`,
+		taskID,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Errorf("no live task with id %s", taskID)
+		return InputError{}
+	}
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func GetChecklistItems(log *logger.BLogger, taskID string) ([]ChecklistItem, error) {
+	if _, err := uuid.Parse(taskID); err != nil {
+		log.Errorf("invalid task_id: %v", err)
+		return nil, InputError{}
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT
+				id,
+				created_at,
+				updated_at,
+				text,
+				done,
+				position,
+				edited
+				FROM checklist_items
+				WHERE task_id = $1
+				ORDER BY position`,
+		taskID,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items = []ChecklistItem{}
+	for rows.Next() {
+		var id, position int
+		var text string
+		var done, edited bool
+		var cAt, uAt time.Time
+		rows.Scan(&id, &cAt, &uAt, &text, &done, &position, &edited)
+		items = append(items, ChecklistItem{id, taskID, cAt, uAt, text, done, position, edited})
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	return items, nil
+}
+
+func GetChecklistItemByID(log *logger.BLogger, itemID int) (*ChecklistItem, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	var id, position int
+	var taskID, text string
+	var done, edited bool
+	var cAt, uAt time.Time
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT
+				id,
+				task_id,
+				created_at,
+				updated_at,
+				text,
+				done,
+				position,
+				edited
+				FROM checklist_items
+				WHERE id = $1`,
+		itemID,
+	).Scan(&id, &taskID, &cAt, &uAt, &text, &done, &position, &edited)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+
+	return &ChecklistItem{id, taskID, cAt, uAt, text, done, position, edited}, nil
+}
+
+// CreateChecklistItem adds an item to the end of a task's checklist
+func CreateChecklistItem(log *logger.BLogger, createReq CreateChecklistItemReq) (*ChecklistItem, error) {
+	if createReq.Text == "" || createReq.TaskID == "" {
+		log.Error("createChecklistItem: Text or TaskID blank")
+		return nil, InputError{}
+	}
+	if _, err := uuid.Parse(createReq.TaskID); err != nil {
+		log.Errorf("invalid task_id: %v", err)
+		return nil, InputError{}
+	}
+	if err := checkMaxLens(log, lengthCheck{"comment_max_len", createReq.Text}); err != nil {
+		return nil, err
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	if err := lockChecklistTask(log, tx, createReq.TaskID); err != nil {
+		return nil, err
+	}
+
+	var id, position int
+	var text string
+	var done, edited bool
+	var cAt, uAt time.Time
+
+	err = tx.QueryRow(context.Background(),
+		`INSERT INTO checklist_items (
+				updated_at,
+				text,
+				task_id,
+				position
+			) VALUES (
+				CURRENT_TIMESTAMP,
+				$1,
+				$2,
+				(SELECT COALESCE(MAX(position) + 1, 0) FROM checklist_items WHERE task_id = $2)
+			) RETURNING
+				id,
+				created_at,
+				updated_at,
+				text,
+				done,
+				position,
+				edited`,
+		createReq.Text,
+		createReq.TaskID,
+	).Scan(&id, &cAt, &uAt, &text, &done, &position, &edited)
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return nil, err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	return &ChecklistItem{id, createReq.TaskID, cAt, uAt, text, done, position, edited}, nil
+}
+
+// PutChecklistItem sets an item's text and done flag.  Only a change
+// to the text marks the item as edited.
+func PutChecklistItem(log *logger.BLogger, putReq PutChecklistItemReq) (*ChecklistItem, error) {
+	if putReq.Text == "" {
+		log.Error("putChecklistItem: Text blank")
+		return nil, InputError{}
+	}
+	if putReq.UpdatedAt.IsZero() {
+		log.Error("putChecklistItem: UpdatedAt blank")
+		return nil, InputError{}
+	}
+	if err := checkMaxLens(log, lengthCheck{"comment_max_len", putReq.Text}); err != nil {
+		return nil, err
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	var id, position int
+	var taskID, text string
+	var done, edited bool
+	var cAt, uAt time.Time
+
+	err = conn.QueryRow(context.Background(),
+		`UPDATE checklist_items SET
+			updated_at = CURRENT_TIMESTAMP,
+			edited = edited OR text <> $1,
+			text = $1,
+			done = $2
+			WHERE id = $3 AND updated_at = $4
+			RETURNING
+				id,
+				task_id,
+				created_at,
+				updated_at,
+				text,
+				done,
+				position,
+				edited`,
+		putReq.Text,
+		putReq.Done,
+		putReq.ID,
+		putReq.UpdatedAt,
+	).Scan(&id, &taskID, &cAt, &uAt, &text, &done, &position, &edited)
+	if errors.Is(err, pgx.ErrNoRows) {
+		current, err := GetChecklistItemByID(log, putReq.ID)
+		if err != nil {
+			return nil, InputError{}
+		}
+		return nil, ConflictError{current}
+	}
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return nil, err
+	}
+
+	return &ChecklistItem{id, taskID, cAt, uAt, text, done, position, edited}, nil
+}
+
+// ReorderChecklistItems sets the order of a task's checklist.  ItemIDs must
+// hold each of the task's items exactly once.  Reordering leaves updated_at
+// alone, so it does not conflict with an edit to an item's text.
+func ReorderChecklistItems(log *logger.BLogger, reorderReq ReorderChecklistItemsReq) ([]ChecklistItem, error) {
+	if reorderReq.TaskID == "" {
+		log.Error("reorderChecklistItems: TaskID blank")
+		return nil, InputError{}
+	}
+	if _, err := uuid.Parse(reorderReq.TaskID); err != nil {
+		log.Errorf("invalid task_id: %v", err)
+		return nil, InputError{}
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	if err := lockChecklistTask(log, tx, reorderReq.TaskID); err != nil {
+		return nil, err
+	}
+
+	var total int
+	err = tx.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM checklist_items WHERE task_id = $1`,
+		reorderReq.TaskID,
+	).Scan(&total)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	seen := make(map[int]bool, len(reorderReq.ItemIDs))
+	for _, id := range reorderReq.ItemIDs {
+		if seen[id] {
+			log.Errorf("reorderChecklistItems: item %d given more than once", id)
+			return nil, InputError{}
+		}
+		seen[id] = true
+	}
+	if len(reorderReq.ItemIDs) != total {
+		log.Errorf("reorderChecklistItems: got %d items, task has %d", len(reorderReq.ItemIDs), total)
+		return nil, InputError{}
+	}
+
+	// positions are swapped in one statement, relying on the deferrable unique constraint
+	tag, err := tx.Exec(context.Background(),
+		`UPDATE checklist_items ci SET
+			position = o.position - 1
+			FROM unnest($2::int[]) WITH ORDINALITY AS o(id, position)
+			WHERE ci.id = o.id AND ci.task_id = $1`,
+		reorderReq.TaskID,
+		reorderReq.ItemIDs,
+	)
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return nil, err
+	}
+	if tag.RowsAffected() != int64(total) {
+		log.Errorf("reorderChecklistItems: %d of %d items belong to task %s", tag.RowsAffected(), total, reorderReq.TaskID)
+		return nil, InputError{}
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	return GetChecklistItems(log, reorderReq.TaskID)
+}
+
+// DestroyChecklistItem deletes an item and closes the gap it leaves in
+// its task's checklist.  Checklist items do not go to the trash.
+func DestroyChecklistItem(log *logger.BLogger, destroyReq DestroyChecklistItemReq) error {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	var taskID string
+	err = tx.QueryRow(context.Background(),
+		`SELECT task_id FROM checklist_items WHERE id = $1`,
+		destroyReq.ID,
+	).Scan(&taskID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Errorf("destroyChecklistItem: no checklist item with id %d", destroyReq.ID)
+		return InputError{}
+	}
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return err
+	}
+	if err := lockChecklistTask(log, tx, taskID); err != nil {
+		return err
+	}
+
+	var position int
+	err = tx.QueryRow(context.Background(),
+		`DELETE FROM checklist_items WHERE id = $1 RETURNING position`,
+		destroyReq.ID,
+	).Scan(&position)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Errorf("destroyChecklistItem: no checklist item with id %d", destroyReq.ID)
+		return InputError{}
+	}
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return err
+	}
+
+	_, err = tx.Exec(context.Background(),
+		`UPDATE checklist_items SET
+			position = position - 1
+			WHERE task_id = $1 AND position > $2`,
+		taskID,
+		position,
+	)
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
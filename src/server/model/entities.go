@@ -1,20 +1,42 @@
 package model
 
-import "time"
+import (
+	"net/url"
+	"time"
+)
 
 type Task struct {
-	ID           string    `json:"id"`
-	Sqid         string    `json:"sqid"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Title        string    `json:"title"`
-	Description  string    `json:"description"`
-	Status       string    `json:"status"`
-	StoryID      *string   `json:"story_id"`
-	BucketID     *string   `json:"bucket_id"`
-	Edited       bool      `json:"edited"`
-	BulkTask     bool      `json:"bulk_task"`
-	CommentCount *int      `json:"comment_count,omitempty"`
+	ID           string             `json:"id"`
+	Sqid         string             `json:"sqid"`
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+	Title        string             `json:"title"`
+	Description  string             `json:"description"`
+	Status       string             `json:"status"`
+	StoryID      *string            `json:"story_id"`
+	BucketID     *string            `json:"bucket_id"`
+	Edited       bool               `json:"edited"`
+	BulkTask     bool               `json:"bulk_task"`
+	CommentCount *int               `json:"comment_count,omitempty"`
+	Checklist    *ChecklistProgress `json:"checklist,omitempty"`
+}
+
+// ChecklistProgress counts the checklist items of a task which are done
+type ChecklistProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// TaskWithBlockers is a task along with the live tasks which block it
+type TaskWithBlockers struct {
+	Task
+	Blockers []Task `json:"blockers"`
+}
+
+// UpdatedTask is a task as returned by an update, with any warnings about the change
+type UpdatedTask struct {
+	Task
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 type Comment struct {
@@ -26,16 +48,34 @@ type Comment struct {
 	Edited    bool      `json:"edited"`
 }
 
+type ChecklistItem struct {
+	ID        int       `json:"id"`
+	TaskID    string    `json:"task_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Text      string    `json:"text"`
+	Done      bool      `json:"done"`
+	Position  int       `json:"position"`
+	Edited    bool      `json:"edited"`
+}
+
 type Tag struct {
 	ID          string    `json:"id"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
+	ParentID    *string   `json:"parent_id"`
 	IsParent    bool      `json:"is_parent"`
 	Edited      bool      `json:"edited"`
 }
 
+// TagNode is a tag along with its child tags, as returned by get_tags?format=tree
+type TagNode struct {
+	Tag
+	Children []TagNode `json:"children"`
+}
+
 type TagAssignment struct {
 	ID        int       `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
@@ -43,6 +83,21 @@ type TagAssignment struct {
 	StoryID   string    `json:"story_id"`
 }
 
+type TaskTagAssignment struct {
+	ID        int       `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	TagID     string    `json:"tag_id"`
+	TaskID    string    `json:"task_id"`
+}
+
+// TaskTag is a tag which applies to a task, either assigned to the task
+// itself or inherited from its story or bucket
+type TaskTag struct {
+	TaskID string `json:"task_id"`
+	TagID  string `json:"tag_id"`
+	Source string `json:"source"` // task, story or bucket
+}
+
 type BucketTagAssignment struct {
 	ID        int       `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
@@ -83,6 +138,12 @@ type Bucket struct {
 	Edited      bool      `json:"edited"`
 }
 
+// BucketWithTasks is a bucket along with every live task in it
+type BucketWithTasks struct {
+	Bucket
+	Tasks []Task `json:"tasks"`
+}
+
 type StoryRelationship struct {
 	ID        int       `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
@@ -91,6 +152,31 @@ type StoryRelationship struct {
 	Relation  string    `json:"relation"`
 }
 
+// StoryGraph is every story which transitively blocks a story, and every story it transitively blocks
+type StoryGraph struct {
+	StoryID    string           `json:"story_id"`
+	Blockers   []StoryGraphNode `json:"blockers"`
+	Dependents []StoryGraphNode `json:"dependents"`
+}
+
+// StoryGraphNode is a story in a StoryGraph.
+// Depth is the number of BLOCKS relationships between it and the graph's story.
+type StoryGraphNode struct {
+	StoryID string `json:"story_id"`
+	Sqid    string `json:"sqid"`
+	Title   string `json:"title"`
+	Status  string `json:"status"`
+	Depth   int    `json:"depth"`
+}
+
+type TaskRelationship struct {
+	ID        int       `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	TaskIDA   string    `json:"task_id_a"`
+	TaskIDB   string    `json:"task_id_b"`
+	Relation  string    `json:"relation"`
+}
+
 // SessionRecord contains a Session which is used to manage logged in users
 // The struct is so named, since this is really a representation of what's in the database
 // and contains record-level information (e.g. UpdatedAt) which is not used for business logic
@@ -143,3 +229,176 @@ type UploadArtifact struct {
 	MimeType     string
 	SHA256Hex    string
 }
+
+// Revision is a single recorded change to a task, story or comment
+type Revision struct {
+	ID         int                    `json:"id"`
+	CreatedAt  time.Time              `json:"created_at"`
+	EntityKind string                 `json:"entity_kind"`
+	EntityID   string                 `json:"entity_id"`
+	Action     string                 `json:"action"`
+	Changes    map[string]FieldChange `json:"changes"`
+}
+
+// FieldChange is the value of a field before and after a revision;
+// nil means the field was null, or the entity did not exist yet
+type FieldChange struct {
+	Old *string `json:"old"`
+	New *string `json:"new"`
+}
+
+// SprintCalendarIssue is two sprints which overlap, or a gap between consecutive sprints.
+// StartDate and EndDate are the days the sprints share, or the days no sprint covers.
+type SprintCalendarIssue struct {
+	Kind      string `json:"kind"`
+	SprintIDA string `json:"sprint_id_a"`
+	SprintIDB string `json:"sprint_id_b"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+// SprintRollover lists what a sprint rollover changed.
+// For a dry run it lists what would have changed; ids of new entities
+// are the ones they would have had.
+type SprintRollover struct {
+	DryRun            bool              `json:"dry_run"`
+	Sprint            Sprint            `json:"sprint"`
+	NextSprint        Sprint            `json:"next_sprint"`
+	CreatedNextSprint bool              `json:"created_next_sprint"`
+	Stories           []RolledOverStory `json:"stories"`
+}
+
+// RolledOverStory is an unfinished story and the story continuing it in the next sprint
+type RolledOverStory struct {
+	From                Story            `json:"from"`
+	To                  Story            `json:"to"`
+	StoryRelationshipID int              `json:"story_relationship_id"`
+	Tasks               []RolledOverTask `json:"tasks"`
+	TagIDs              []string         `json:"tag_ids"`
+}
+
+// RolledOverTask is an open task of an unfinished story and its clone in the continuing story
+type RolledOverTask struct {
+	From Task `json:"from"`
+	To   Task `json:"to"`
+}
+
+// SprintReport is the progress of a sprint, along with the velocity of recent sprints
+type SprintReport struct {
+	Sprint      Sprint             `json:"sprint"`
+	Burndown    []BurndownPoint    `json:"burndown"`
+	Velocity    []SprintVelocity   `json:"velocity"`
+	CarriedOver []CarriedOverStory `json:"carried_over"`
+}
+
+// BurndownPoint is the state of a sprint's tasks at the end of a day
+type BurndownPoint struct {
+	Date      string `json:"date"`
+	Total     int    `json:"total"`
+	Completed int    `json:"completed"`
+	Remaining int    `json:"remaining"`
+}
+
+// SprintVelocity is how much work was finished in a sprint
+type SprintVelocity struct {
+	SprintID         string `json:"sprint_id"`
+	SprintTitle      string `json:"sprint_title"`
+	TotalTasks       int    `json:"total_tasks"`
+	CompletedTasks   int    `json:"completed_tasks"`
+	TotalStories     int    `json:"total_stories"`
+	CompletedStories int    `json:"completed_stories"`
+}
+
+// CarriedOverStory is a story which was continued by a story in another sprint
+type CarriedOverStory struct {
+	StoryID             string `json:"story_id"`
+	Sqid                string `json:"sqid"`
+	Title               string `json:"title"`
+	ContinuedByID       string `json:"continued_by_id"`
+	ContinuedBySqid     string `json:"continued_by_sqid"`
+	ContinuedBySprintID string `json:"continued_by_sprint_id"`
+}
+
+// SprintFlowMetrics are the cycle time analytics for the tasks and stories of a sprint
+type SprintFlowMetrics struct {
+	SprintID    string      `json:"sprint_id"`
+	SprintTitle string      `json:"sprint_title"`
+	Tasks       FlowMetrics `json:"tasks"`
+	Stories     FlowMetrics `json:"stories"`
+}
+
+// FlowMetrics summarizes how long entities took to move through their statuses.
+// Lead time runs from creation to DONE, cycle time from first DOING to DONE.
+// Only entities whose current status is DONE have a lead or cycle time.
+type FlowMetrics struct {
+	LeadTime     DurationPercentiles            `json:"lead_time"`
+	CycleTime    DurationPercentiles            `json:"cycle_time"`
+	TimeInStatus map[string]DurationPercentiles `json:"time_in_status"`
+}
+
+// DurationPercentiles are nearest-rank percentiles of a set of durations, in seconds
+type DurationPercentiles struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50"`
+	P75   float64 `json:"p75"`
+	P90   float64 `json:"p90"`
+	Max   float64 `json:"max"`
+}
+
+// TrashItem is a soft deleted entity of any kind, as listed by the trash
+type TrashItem struct {
+	Kind      string    `json:"kind"`
+	ID        string    `json:"id"`
+	Sqid      *string   `json:"sqid"`
+	Title     string    `json:"title"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// SearchResult is a task, story, bucket or comment matching a search.
+// A comment is identified by its id, and carries the sqid, title, status
+// and sprint of its task.
+type SearchResult struct {
+	Kind     string  `json:"kind"`
+	ID       string  `json:"id"`
+	Sqid     string  `json:"sqid"`
+	Title    string  `json:"title"`
+	Status   string  `json:"status"`
+	SprintID *string `json:"sprint_id"`
+	Rank     float32 `json:"rank"`
+	Snippet  string  `json:"snippet"` // HTML escaped, with matches wrapped in <mark></mark>
+}
+
+// SavedView is a named filter over tasks or stories.  Filter holds the
+// query parameters of get_tasks or get_stories, except cursor and limit.
+type SavedView struct {
+	ID         string     `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Name       string     `json:"name"`
+	EntityKind string     `json:"entity_kind"`
+	Filter     url.Values `json:"filter"`
+	Edited     bool       `json:"edited"`
+}
+
+// ViewResults is a page of the tasks or stories matching a saved view
+type ViewResults struct {
+	View    SavedView    `json:"view"`
+	Tasks   *Page[Task]  `json:"tasks,omitempty"`
+	Stories *Page[Story] `json:"stories,omitempty"`
+}
+
+// BulkUpdateResult reports a bulk update.  Nothing is written unless Applied.
+type BulkUpdateResult struct {
+	Applied bool             `json:"applied"`
+	Items   []BulkItemResult `json:"items"`
+}
+
+// BulkItemResult is the outcome for one id of a bulk update.
+// Task or Story is the entity after the update.
+type BulkItemResult struct {
+	ID       string   `json:"id"`
+	Error    string   `json:"error,omitempty"`
+	Task     *Task    `json:"task,omitempty"`
+	Story    *Story   `json:"story,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
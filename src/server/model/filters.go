@@ -0,0 +1,389 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultListLimit = 200
+	MaxListLimit     = 1000
+)
+
+// Page is one page of a GetMany API.  NextCursor is nil on the last page,
+// otherwise it is passed back as ?cursor= to fetch the following page.
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+// sortField is a column which a list may be sorted by
+type sortField struct {
+	column  string
+	sqlType string // for casting cursor values back to the column's type
+}
+
+var taskSortFields = map[string]sortField{
+	"created_at": {"created_at", "timestamp"},
+	"updated_at": {"updated_at", "timestamp"},
+	"title":      {"title", "text"},
+	"status":     {"status", "task_status"},
+}
+
+var storySortFields = map[string]sortField{
+	"created_at": {"created_at", "timestamp"},
+	"updated_at": {"updated_at", "timestamp"},
+	"title":      {"title", "text"},
+	"status":     {"status", "story_status"},
+}
+
+var sprintSortFields = map[string]sortField{
+	"created_at": {"created_at", "timestamp"},
+	"updated_at": {"updated_at", "timestamp"},
+	"title":      {"title", "text"},
+	"start_date": {"start_date", "date"},
+	"end_date":   {"end_date", "date"},
+}
+
+var tagSortFields = map[string]sortField{
+	"created_at": {"created_at", "timestamp"},
+	"updated_at": {"updated_at", "timestamp"},
+	"title":      {"title", "text"},
+}
+
+// listCursor is the sort value and id of the last item of a page.
+// Clients only ever see it encoded.
+type listCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    string `json:"i"`
+}
+
+func (c listCursor) encode() string {
+	js, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(js)
+}
+
+// ListParams are the sort and pagination parameters shared by the GetMany APIs.
+// ?sort=title sorts ascending and ?sort=-title descending; ties are broken by id.
+type ListParams struct {
+	Sort       string
+	Descending bool
+	Limit      int
+	After      *listCursor
+	field      sortField
+}
+
+// TimeRange bounds a timestamp; either end may be nil
+type TimeRange struct {
+	After  *time.Time
+	Before *time.Time
+}
+
+type TaskFilter struct {
+	ListParams
+	Statuses []string
+	StoryID  *string
+	SprintID *string
+	BucketID *string
+	TagID    *string // also matches its descendants, and tags of the task's story or bucket
+	BulkTask *bool
+	Created  TimeRange
+	Updated  TimeRange
+}
+
+type StoryFilter struct {
+	ListParams
+	Statuses []string
+	SprintID *string
+	TagID    *string // also matches its descendants
+	Created  TimeRange
+	Updated  TimeRange
+}
+
+type SprintFilter struct {
+	ListParams
+	Created TimeRange
+	Updated TimeRange
+}
+
+type TagFilter struct {
+	ListParams
+	Created TimeRange
+	Updated TimeRange
+}
+
+func ParseTaskFilter(log *logger.BLogger, q url.Values) (TaskFilter, error) {
+	var f TaskFilter
+	var err error
+	if f.ListParams, err = parseListParams(log, q, taskSortFields); err != nil {
+		return f, err
+	}
+	if f.Statuses, err = parseStatuses(log, q, taskStatuses); err != nil {
+		return f, err
+	}
+	if f.StoryID, err = parseUUIDParam(log, q, "story_id"); err != nil {
+		return f, err
+	}
+	if f.SprintID, err = parseUUIDParam(log, q, "sprint_id"); err != nil {
+		return f, err
+	}
+	if f.BucketID, err = parseUUIDParam(log, q, "bucket_id"); err != nil {
+		return f, err
+	}
+	if f.TagID, err = parseUUIDParam(log, q, "tag_id"); err != nil {
+		return f, err
+	}
+	if f.BulkTask, err = parseBoolParam(log, q, "bulk_task"); err != nil {
+		return f, err
+	}
+	if f.Created, err = parseTimeRange(log, q, "created"); err != nil {
+		return f, err
+	}
+	f.Updated, err = parseTimeRange(log, q, "updated")
+	return f, err
+}
+
+func ParseStoryFilter(log *logger.BLogger, q url.Values) (StoryFilter, error) {
+	var f StoryFilter
+	var err error
+	if f.ListParams, err = parseListParams(log, q, storySortFields); err != nil {
+		return f, err
+	}
+	if f.Statuses, err = parseStatuses(log, q, storyStatuses); err != nil {
+		return f, err
+	}
+	if f.SprintID, err = parseUUIDParam(log, q, "sprint_id"); err != nil {
+		return f, err
+	}
+	if f.TagID, err = parseUUIDParam(log, q, "tag_id"); err != nil {
+		return f, err
+	}
+	if f.Created, err = parseTimeRange(log, q, "created"); err != nil {
+		return f, err
+	}
+	f.Updated, err = parseTimeRange(log, q, "updated")
+	return f, err
+}
+
+func ParseSprintFilter(log *logger.BLogger, q url.Values) (SprintFilter, error) {
+	var f SprintFilter
+	var err error
+	if f.ListParams, err = parseListParams(log, q, sprintSortFields); err != nil {
+		return f, err
+	}
+	if f.Created, err = parseTimeRange(log, q, "created"); err != nil {
+		return f, err
+	}
+	f.Updated, err = parseTimeRange(log, q, "updated")
+	return f, err
+}
+
+func ParseTagFilter(log *logger.BLogger, q url.Values) (TagFilter, error) {
+	var f TagFilter
+	var err error
+	if f.ListParams, err = parseListParams(log, q, tagSortFields); err != nil {
+		return f, err
+	}
+	if f.Created, err = parseTimeRange(log, q, "created"); err != nil {
+		return f, err
+	}
+	f.Updated, err = parseTimeRange(log, q, "updated")
+	return f, err
+}
+
+// parseListParams reads ?sort, ?limit and ?cursor.  A cursor is only
+// valid with the sort it was issued for.
+func parseListParams(log *logger.BLogger, q url.Values, fields map[string]sortField) (ListParams, error) {
+	p := ListParams{Sort: "created_at", Limit: DefaultListLimit}
+	if sort := q.Get("sort"); sort != "" {
+		p.Sort = strings.TrimPrefix(sort, "-")
+		p.Descending = strings.HasPrefix(sort, "-")
+	}
+	field, ok := fields[p.Sort]
+	if !ok {
+		log.Errorf("cannot sort by %q", p.Sort)
+		return p, InputError{}
+	}
+	p.field = field
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > MaxListLimit {
+			log.Errorf("limit must be between 1 and %d, got %q", MaxListLimit, limit)
+			return p, InputError{}
+		}
+		p.Limit = n
+	}
+
+	if cursor := q.Get("cursor"); cursor != "" {
+		js, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			log.Errorf("malformed cursor: %v", err)
+			return p, InputError{}
+		}
+		var c listCursor
+		if err := json.Unmarshal(js, &c); err != nil {
+			log.Errorf("malformed cursor: %v", err)
+			return p, InputError{}
+		}
+		if _, err := uuid.Parse(c.ID); err != nil {
+			log.Errorf("malformed cursor: %v", err)
+			return p, InputError{}
+		}
+		if c.Sort != p.Sort || c.Desc != p.Descending {
+			log.Errorf("cursor was issued for a different sort than %q", q.Get("sort"))
+			return p, InputError{}
+		}
+		p.After = &c
+	}
+
+	return p, nil
+}
+
+// parseStatuses reads ?status, which may be repeated or comma separated
+func parseStatuses(log *logger.BLogger, q url.Values, valid map[string]bool) ([]string, error) {
+	var statuses []string
+	for _, param := range q["status"] {
+		for _, status := range strings.Split(param, ",") {
+			if !valid[status] {
+				log.Errorf("invalid status %q", status)
+				return nil, InputError{}
+			}
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
+func parseUUIDParam(log *logger.BLogger, q url.Values, key string) (*string, error) {
+	v := q.Get(key)
+	if v == "" {
+		return nil, nil
+	}
+	if _, err := uuid.Parse(v); err != nil {
+		log.Errorf("invalid %s: %v", key, err)
+		return nil, InputError{}
+	}
+	return &v, nil
+}
+
+func parseBoolParam(log *logger.BLogger, q url.Values, key string) (*bool, error) {
+	v := q.Get(key)
+	if v == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Errorf("invalid %s: %v", key, err)
+		return nil, InputError{}
+	}
+	return &b, nil
+}
+
+// parseTimeRange reads ?<prefix>_after and ?<prefix>_before,
+// each either an RFC 3339 timestamp or a date
+func parseTimeRange(log *logger.BLogger, q url.Values, prefix string) (TimeRange, error) {
+	var r TimeRange
+	for _, bound := range []struct {
+		key string
+		dst **time.Time
+	}{
+		{prefix + "_after", &r.After},
+		{prefix + "_before", &r.Before},
+	} {
+		v := q.Get(bound.key)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			t, err = time.Parse("2006-01-02", v)
+		}
+		if err != nil {
+			log.Errorf("invalid %s: %q", bound.key, v)
+			return r, InputError{}
+		}
+		*bound.dst = &t
+	}
+	return r, nil
+}
+
+// listQuery accumulates the WHERE conditions and args of a GetMany query
+type listQuery struct {
+	conds []string
+	args  []interface{}
+}
+
+// arg adds an argument and returns its placeholder
+func (lq *listQuery) arg(v interface{}) string {
+	lq.args = append(lq.args, v)
+	return fmt.Sprintf("$%d", len(lq.args))
+}
+
+func (lq *listQuery) where(cond string) {
+	lq.conds = append(lq.conds, cond)
+}
+
+func (lq *listQuery) timeRange(column string, r TimeRange) {
+	if r.After != nil {
+		lq.where(column + " >= " + lq.arg(*r.After))
+	}
+	if r.Before != nil {
+		lq.where(column + " < " + lq.arg(*r.Before))
+	}
+}
+
+// whereSQL joins the conditions, including the one which resumes after
+// the cursor.  alias qualifies the sort and id columns, e.g. "t."
+func (lq *listQuery) whereSQL(p ListParams, alias string) string {
+	if p.After != nil {
+		op := ">"
+		if p.Descending {
+			op = "<"
+		}
+		lq.where(fmt.Sprintf("(%s%s, %sid) %s (CAST(%s AS %s), CAST(%s AS uuid))",
+			alias, p.field.column, alias, op,
+			lq.arg(p.After.Value), p.field.sqlType, lq.arg(p.After.ID)))
+	}
+	if len(lq.conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(lq.conds, " AND ")
+}
+
+// sortValueSQL selects the sort column as text, for building the next cursor
+func sortValueSQL(p ListParams, alias string) string {
+	return alias + p.field.column + "::text"
+}
+
+// orderSQL sorts by the sort column then id, and fetches one row past
+// the page so that nextCursor can tell whether there is another page
+func orderSQL(p ListParams, alias string) string {
+	dir := "ASC"
+	if p.Descending {
+		dir = "DESC"
+	}
+	return fmt.Sprintf("ORDER BY %s%s %s, %sid %s LIMIT %d",
+		alias, p.field.column, dir, alias, dir, p.Limit+1)
+}
+
+// nextCursor trims the extra row fetched by orderSQL, returning the cursor
+// for the following page or nil if this is the last one
+func nextCursor[T any](p ListParams, items []T, sortValues, ids []string) ([]T, *string) {
+	if len(items) <= p.Limit {
+		return items, nil
+	}
+	last := p.Limit - 1
+	cursor := listCursor{p.Sort, p.Descending, sortValues[last], ids[last]}.encode()
+	return items[:p.Limit], &cursor
+}
@@ -0,0 +1,170 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/bschlaman/todo-app/database"
+	"github.com/jackc/pgx/v4"
+)
+
+// kinds of entities whose changes are recorded
+const (
+	HistoryKindTask    = "task"
+	HistoryKindStory   = "story"
+	HistoryKindComment = "comment"
+)
+
+// actions which produce a revision
+const (
+	HistoryActionCreate  = "create"
+	HistoryActionUpdate  = "update"
+	HistoryActionDestroy = "destroy"
+	HistoryActionRestore = "restore"
+)
+
+// taskHistoryFields returns the fields of a task which are tracked in its history
+func taskHistoryFields(t *Task) map[string]*string {
+	return map[string]*string{
+		"title":       &t.Title,
+		"description": &t.Description,
+		"status":      &t.Status,
+		"story_id":    t.StoryID,
+		"bucket_id":   t.BucketID,
+	}
+}
+
+// storyHistoryFields returns the fields of a story which are tracked in its history
+func storyHistoryFields(s *Story) map[string]*string {
+	return map[string]*string{
+		"title":       &s.Title,
+		"description": &s.Description,
+		"status":      &s.Status,
+		"sprint_id":   &s.SprintID,
+	}
+}
+
+// commentHistoryFields returns the fields of a comment which are tracked in its history
+func commentHistoryFields(c *Comment) map[string]*string {
+	return map[string]*string{
+		"text": &c.Text,
+	}
+}
+
+// diffFields returns the fields whose values differ between before and after.
+// Pass a nil before for an entity which is being created.
+func diffFields(before, after map[string]*string) map[string]FieldChange {
+	changes := map[string]FieldChange{}
+	for field, newVal := range after {
+		oldVal := before[field]
+		if oldVal == nil && newVal == nil {
+			continue
+		}
+		if oldVal != nil && newVal != nil && *oldVal == *newVal {
+			continue
+		}
+		changes[field] = FieldChange{oldVal, newVal}
+	}
+	return changes
+}
+
+// recordHistory writes a revision of an entity as part of tx, so that
+// the revision is only kept if the change itself is committed.
+// An update which changes no tracked field is not recorded.
+func recordHistory(log *logger.BLogger, tx pgx.Tx, kind, entityID, action string, changes map[string]FieldChange) error {
+	if action == HistoryActionUpdate && len(changes) == 0 {
+		return nil
+	}
+
+	if changes == nil {
+		changes = map[string]FieldChange{}
+	}
+	js, err := json.Marshal(changes)
+	if err != nil {
+		log.Errorf("json.Marshal failed: %v", err)
+		return err
+	}
+
+	_, err = tx.Exec(context.Background(),
+		`INSERT INTO entity_history (
+				entity_kind,
+				entity_id,
+				action,
+				changes
+			) VALUES ($1, $2, $3, $4)`,
+		kind,
+		entityID,
+		action,
+		string(js),
+	)
+	if err != nil {
+		log.Errorf("failed to record history: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetEntityHistory returns every revision of the task or story with the given sqid, oldest first
+func GetEntityHistory(log *logger.BLogger, kind, sqid string) ([]Revision, error) {
+	var table string
+	switch kind {
+	case HistoryKindTask:
+		table = "tasks"
+	case HistoryKindStory:
+		table = "stories"
+	default:
+		log.Errorf("getEntityHistory: unknown kind: %s", kind)
+		return nil, InputError{}
+	}
+	if sqid == "" {
+		log.Error("getEntityHistory: sqid blank")
+		return nil, InputError{}
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT
+				h.id,
+				h.created_at,
+				h.entity_kind,
+				h.entity_id,
+				h.action,
+				h.changes
+				FROM entity_history h
+				JOIN `+table+` e ON h.entity_id = e.id::text
+				WHERE h.entity_kind = $1 AND e.sqid = $2
+				ORDER BY h.id`,
+		kind,
+		sqid,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions = []Revision{}
+	for rows.Next() {
+		var id int
+		var entityKind, entityID, action string
+		var cAt time.Time
+		var changes map[string]FieldChange
+		rows.Scan(&id, &cAt, &entityKind, &entityID, &action, &changes)
+		revisions = append(revisions, Revision{id, cAt, entityKind, entityID, action, changes})
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	return revisions, nil
+}
@@ -2,11 +2,14 @@ package model
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/bschlaman/b-utils/pkg/logger"
 	"github.com/bschlaman/todo-app/database"
+	"github.com/jackc/pgx/v4"
 	"github.com/sqids/sqids-go"
 )
 
@@ -16,6 +19,16 @@ func (e InputError) Error() string {
 	return "input invalid"
 }
 
+// ConflictError is returned by Put functions when the row has changed
+// since the client last read it.  Current holds the row as it is now.
+type ConflictError struct {
+	Current interface{}
+}
+
+func (e ConflictError) Error() string {
+	return "entity modified since last read"
+}
+
 // TODO (2022.09.30): find a better way of logging here.
 // Do I even need to log in this package?
 // after some more thought, I really dont think I should be logging here
@@ -87,7 +100,7 @@ func GetCommentsByTaskID(log *logger.BLogger, taskID string) ([]Comment, error)
 				text,
 				edited
 				FROM comments
-				WHERE task_id = $1`,
+				WHERE task_id = $1 AND deleted_at IS NULL`,
 		taskID,
 	)
 	if err != nil {
@@ -113,6 +126,39 @@ func GetCommentsByTaskID(log *logger.BLogger, taskID string) ([]Comment, error)
 	return comments, nil
 }
 
+func GetCommentByID(log *logger.BLogger, commentID int) (*Comment, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	var id int
+	var taskID, text string
+	var edited bool
+	var cAt, uAt time.Time
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT
+				id,
+				task_id,
+				created_at,
+				updated_at,
+				text,
+				edited
+				FROM comments
+				WHERE id = $1 AND deleted_at IS NULL`,
+		commentID,
+	).Scan(&id, &taskID, &cAt, &uAt, &text, &edited)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+
+	return &Comment{id, taskID, cAt, uAt, text, edited}, nil
+}
+
 func GetTaskBySQID(log *logger.BLogger, taskSQID string) (*Task, error) {
 	conn, err := database.GetPgxConn()
 	if err != nil {
@@ -125,6 +171,7 @@ func GetTaskBySQID(log *logger.BLogger, taskSQID string) (*Task, error) {
 	var storyID, bucketID *string
 	var cAt, uAt time.Time
 	var edited, bulkTask bool
+	var checklist ChecklistProgress
 
 	err = conn.QueryRow(context.Background(),
 		`SELECT
@@ -138,17 +185,18 @@ func GetTaskBySQID(log *logger.BLogger, taskSQID string) (*Task, error) {
 				story_id,
 				bucket_id,
 				edited,
-				bulk_task
+				bulk_task,
+				`+checklistProgressSQL("tasks.")+`
 				FROM tasks
-				WHERE sqid = $1`,
+				WHERE sqid = $1 AND deleted_at IS NULL`,
 		taskSQID,
-	).Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &storyID, &bucketID, &edited, &bulkTask)
+	).Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &storyID, &bucketID, &edited, &bulkTask, &checklist.Done, &checklist.Total)
 	if err != nil {
 		log.Errorf("Query failed: %v", err)
 		return nil, err
 	}
 
-	return &Task{id, sqid, cAt, uAt, title, desc, status, storyID, bucketID, edited, bulkTask, nil}, nil
+	return &Task{id, sqid, cAt, uAt, title, desc, status, storyID, bucketID, edited, bulkTask, nil, &checklist}, nil
 }
 
 // LEGACY: used for getting by UUIDv4
@@ -164,6 +212,7 @@ func GetTaskByID(log *logger.BLogger, taskID string) (*Task, error) {
 	var storyID, bucketID *string
 	var cAt, uAt time.Time
 	var edited, bulkTask bool
+	var checklist ChecklistProgress
 
 	err = conn.QueryRow(context.Background(),
 		`SELECT
@@ -177,17 +226,18 @@ func GetTaskByID(log *logger.BLogger, taskID string) (*Task, error) {
 				story_id,
 				bucket_id,
 				edited,
-				bulk_task
+				bulk_task,
+				`+checklistProgressSQL("tasks.")+`
 				FROM tasks
-				WHERE id = $1`,
+				WHERE id = $1 AND deleted_at IS NULL`,
 		taskID,
-	).Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &storyID, &bucketID, &edited, &bulkTask)
+	).Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &storyID, &bucketID, &edited, &bulkTask, &checklist.Done, &checklist.Total)
 	if err != nil {
 		log.Errorf("Query failed: %v", err)
 		return nil, err
 	}
 
-	return &Task{id, sqid, cAt, uAt, title, desc, status, storyID, bucketID, edited, bulkTask, nil}, nil
+	return &Task{id, sqid, cAt, uAt, title, desc, status, storyID, bucketID, edited, bulkTask, nil, &checklist}, nil
 }
 
 func GetStoryBySQID(log *logger.BLogger, storySQID string) (*Story, error) {
@@ -214,9 +264,13 @@ func GetStoryBySQID(log *logger.BLogger, storySQID string) (*Story, error) {
 				sprint_id,
 				edited
 				FROM stories
-				WHERE sqid = $1`,
+				WHERE sqid = $1 AND deleted_at IS NULL`,
 		storySQID,
 	).Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &sprintID, &edited)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Errorf("no live story with sqid %s", storySQID)
+		return nil, InputError{}
+	}
 	if err != nil {
 		log.Errorf("Query failed: %v", err)
 		return nil, err
@@ -250,9 +304,13 @@ func GetStoryByID(log *logger.BLogger, storyID string) (*Story, error) {
 				sprint_id,
 				edited
 				FROM stories
-				WHERE id = $1`,
+				WHERE id = $1 AND deleted_at IS NULL`,
 		storyID,
 	).Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &sprintID, &edited)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Errorf("no live story with id %s", storyID)
+		return nil, InputError{}
+	}
 	if err != nil {
 		log.Errorf("Query failed: %v", err)
 		return nil, err
@@ -261,7 +319,95 @@ func GetStoryByID(log *logger.BLogger, storyID string) (*Story, error) {
 	return &Story{id, sqid, cAt, uAt, title, desc, status, sprintID, edited}, nil
 }
 
-func GetTasks(log *logger.BLogger) ([]Task, error) {
+// GetTasks returns a page of the tasks which are not in the trash,
+// each with its count of live comments
+func GetTasks(log *logger.BLogger, filter TaskFilter) (*Page[Task], error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	lq := listQuery{}
+	lq.where("t.deleted_at IS NULL")
+	if len(filter.Statuses) > 0 {
+		lq.where("t.status::text = ANY(" + lq.arg(filter.Statuses) + ")")
+	}
+	if filter.StoryID != nil {
+		lq.where("t.story_id = " + lq.arg(*filter.StoryID))
+	}
+	if filter.SprintID != nil {
+		lq.where("t.story_id IN (SELECT id FROM stories WHERE sprint_id = " + lq.arg(*filter.SprintID) + ")")
+	}
+	if filter.BucketID != nil {
+		lq.where("t.bucket_id = " + lq.arg(*filter.BucketID))
+	}
+	if filter.TagID != nil {
+		subtree := tagSubtreeSQL(lq.arg(*filter.TagID))
+		lq.where(`(t.id IN (SELECT task_id FROM task_tag_assignments WHERE tag_id IN (` + subtree + `))
+				OR t.story_id IN (SELECT story_id FROM tag_assignments WHERE tag_id IN (` + subtree + `))
+				OR t.bucket_id IN (SELECT bucket_id FROM bucket_tag_assignments WHERE tag_id IN (` + subtree + `)))`)
+	}
+	if filter.BulkTask != nil {
+		lq.where("t.bulk_task = " + lq.arg(*filter.BulkTask))
+	}
+	lq.timeRange("t.created_at", filter.Created)
+	lq.timeRange("t.updated_at", filter.Updated)
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT
+				t.id,
+				t.sqid,
+				t.created_at,
+				t.updated_at,
+				t.title,
+				t.description,
+				t.status,
+				t.story_id,
+				t.bucket_id,
+				t.edited,
+				t.bulk_task,
+				COUNT(c.id) AS comment_count,
+				`+checklistProgressSQL("t.")+`,
+				`+sortValueSQL(filter.ListParams, "t.")+`
+				FROM tasks t
+				LEFT JOIN comments c ON c.task_id = t.id AND c.deleted_at IS NULL
+				`+lq.whereSQL(filter.ListParams, "t.")+`
+				GROUP BY t.id
+				`+orderSQL(filter.ListParams, "t."),
+		lq.args...,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks = []Task{}
+	var sortValues, ids []string
+	for rows.Next() {
+		var id, sqid, title, desc, status, sortValue string
+		var storyID, bucketID *string
+		var cAt, uAt time.Time
+		var edited, bulkTask bool
+		var commentCount int
+		var checklist ChecklistProgress
+		rows.Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &storyID, &bucketID, &edited, &bulkTask, &commentCount, &checklist.Done, &checklist.Total, &sortValue)
+		tasks = append(tasks, Task{id, sqid, cAt, uAt, title, desc, status, storyID, bucketID, edited, bulkTask, &commentCount, &checklist})
+		sortValues = append(sortValues, sortValue)
+		ids = append(ids, id)
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	tasks, cursor := nextCursor(filter.ListParams, tasks, sortValues, ids)
+	return &Page[Task]{tasks, cursor}, nil
+}
+
+func GetTasksByBucketID(log *logger.BLogger, bucketID string) ([]Task, error) {
 	conn, err := database.GetPgxConn()
 	if err != nil {
 		log.Errorf("unable to connect to database: %v", err)
@@ -284,8 +430,10 @@ func GetTasks(log *logger.BLogger) ([]Task, error) {
 				t.bulk_task,
 				COUNT(c.id) AS comment_count
 				FROM tasks t
-				LEFT JOIN comments c ON c.task_id = t.id
+				LEFT JOIN comments c ON c.task_id = t.id AND c.deleted_at IS NULL
+				WHERE t.bucket_id = $1 AND t.deleted_at IS NULL
 				GROUP BY t.id`,
+		bucketID,
 	)
 	if err != nil {
 		log.Errorf("Query failed: %v", err)
@@ -296,12 +444,12 @@ func GetTasks(log *logger.BLogger) ([]Task, error) {
 	var tasks = []Task{}
 	for rows.Next() {
 		var id, sqid, title, desc, status string
-		var storyID, bucketID *string
+		var storyID, bID *string
 		var cAt, uAt time.Time
 		var edited, bulkTask bool
 		var commentCount int
-		rows.Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &storyID, &bucketID, &edited, &bulkTask, &commentCount)
-		tasks = append(tasks, Task{id, sqid, cAt, uAt, title, desc, status, storyID, bucketID, edited, bulkTask, &commentCount})
+		rows.Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &storyID, &bID, &edited, &bulkTask, &commentCount)
+		tasks = append(tasks, Task{id, sqid, cAt, uAt, title, desc, status, storyID, bID, edited, bulkTask, &commentCount, nil})
 	}
 	if rows.Err() != nil {
 		log.Errorf("Query failed: %v", rows.Err())
@@ -319,20 +467,34 @@ func CreateTask(log *logger.BLogger, s *sqids.Sqids, createReq CreateTaskReq) (*
 	}
 	defer conn.Release()
 
+	createReq.StoryID = nilIfBlank(createReq.StoryID)
+	createReq.BucketID = nilIfBlank(createReq.BucketID)
+	if err := validateTaskParent(log, conn, createReq.StoryID, createReq.BucketID); err != nil {
+		return nil, err
+	}
+
 	var id, sqid, title, desc, status string
 	var storyID, bucketID *string
 	var cAt, uAt time.Time
 	var edited, bulkTask bool
 
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
 	// generate the sqid
 	sq, _ := s.Encode([]uint64{uint64(time.Now().UnixNano() / 1e6)})
 
-	err = conn.QueryRow(context.Background(),
+	err = tx.QueryRow(context.Background(),
 		`INSERT INTO tasks (
 				updated_at,
 				title,
 				description,
 				story_id,
+				bucket_id,
 				bulk_task,
 				sqid
 			) VALUES (
@@ -341,7 +503,8 @@ func CreateTask(log *logger.BLogger, s *sqids.Sqids, createReq CreateTaskReq) (*
 				$2,
 				$3,
 				$4,
-				$5
+				$5,
+				$6
 			) RETURNING
 				id,
 				sqid,
@@ -357,6 +520,7 @@ func CreateTask(log *logger.BLogger, s *sqids.Sqids, createReq CreateTaskReq) (*
 		createReq.Title,
 		createReq.Description,
 		createReq.StoryID,
+		createReq.BucketID,
 		createReq.BulkTask,
 		sq,
 	).Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &storyID, &bucketID, &edited, &bulkTask)
@@ -365,7 +529,18 @@ func CreateTask(log *logger.BLogger, s *sqids.Sqids, createReq CreateTaskReq) (*
 		return nil, err
 	}
 
-	return &Task{id, sqid, cAt, uAt, title, desc, status, storyID, bucketID, edited, bulkTask, nil}, nil
+	task := &Task{id, sqid, cAt, uAt, title, desc, status, storyID, bucketID, edited, bulkTask, nil, nil}
+	if err := recordHistory(log, tx, HistoryKindTask, id, HistoryActionCreate, diffFields(nil, taskHistoryFields(task))); err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	return task, nil
 }
 
 func CreateComment(log *logger.BLogger, createReq CreateCommentReq) (*Comment, error) {
@@ -381,12 +556,19 @@ func CreateComment(log *logger.BLogger, createReq CreateCommentReq) (*Comment, e
 	}
 	defer conn.Release()
 
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
 	var id int
 	var text string
 	var edited bool
 	var cAt, uAt time.Time
 
-	err = conn.QueryRow(context.Background(),
+	err = tx.QueryRow(context.Background(),
 		`INSERT INTO comments (
 				updated_at,
 				text,
@@ -409,40 +591,104 @@ func CreateComment(log *logger.BLogger, createReq CreateCommentReq) (*Comment, e
 		return nil, err
 	}
 
-	return &Comment{id, createReq.TaskID, cAt, uAt, text, edited}, nil
+	comment := &Comment{id, createReq.TaskID, cAt, uAt, text, edited}
+	if err := recordHistory(log, tx, HistoryKindComment, strconv.Itoa(id), HistoryActionCreate, diffFields(nil, commentHistoryFields(comment))); err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	return comment, nil
 }
 
-func PutComment(log *logger.BLogger, putReq PutCommentReq) error {
+func PutComment(log *logger.BLogger, putReq PutCommentReq) (*Comment, error) {
 	if putReq.Text == "" {
 		log.Error("putComment: Text blank")
-		return InputError{}
+		return nil, InputError{}
+	}
+	if putReq.UpdatedAt.IsZero() {
+		log.Error("putComment: UpdatedAt blank")
+		return nil, InputError{}
 	}
 
 	conn, err := database.GetPgxConn()
 	if err != nil {
 		log.Errorf("unable to connect to database: %v", err)
-		return err
+		return nil, err
 	}
 	defer conn.Release()
 
-	_, err = conn.Exec(context.Background(),
-		`UPDATE comments SET
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	var id int
+	var taskID, text string
+	var edited bool
+	var cAt, uAt time.Time
+	var before Comment
+
+	// old locks the row and captures its values before the update, for the history
+	err = tx.QueryRow(context.Background(),
+		`WITH old AS (
+			SELECT id, text
+				FROM comments
+				WHERE id = $2 AND updated_at = $3 AND deleted_at IS NULL
+				FOR UPDATE
+		)
+		UPDATE comments SET
 			updated_at = CURRENT_TIMESTAMP,
 			text = $1,
 			edited = true
-			WHERE id = $2`,
+			FROM old
+			WHERE comments.id = old.id AND comments.updated_at = $3
+			RETURNING
+				comments.id,
+				comments.task_id,
+				comments.created_at,
+				comments.updated_at,
+				comments.text,
+				comments.edited,
+				old.text`,
 		putReq.Text,
 		putReq.ID,
-	)
+		putReq.UpdatedAt,
+	).Scan(&id, &taskID, &cAt, &uAt, &text, &edited, &before.Text)
+	if errors.Is(err, pgx.ErrNoRows) {
+		current, err := GetCommentByID(log, putReq.ID)
+		if err != nil {
+			return nil, InputError{}
+		}
+		return nil, ConflictError{current}
+	}
 	if err != nil {
 		log.Errorf("conn.Exec failed: %v", err)
-		return err
+		return nil, err
 	}
 
-	return nil
+	comment := &Comment{id, taskID, cAt, uAt, text, edited}
+	if err := recordHistory(log, tx, HistoryKindComment, strconv.Itoa(id), HistoryActionUpdate, diffFields(commentHistoryFields(&before), commentHistoryFields(comment))); err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	return comment, nil
 }
 
-func PutStory(log *logger.BLogger, putReq PutStoryReq) error {
+// DestroyComment moves a comment to the trash
+func DestroyComment(log *logger.BLogger, destroyReq DestroyCommentReq) error {
 	conn, err := database.GetPgxConn()
 	if err != nil {
 		log.Errorf("unable to connect to database: %v", err)
@@ -450,69 +696,517 @@ func PutStory(log *logger.BLogger, putReq PutStoryReq) error {
 	}
 	defer conn.Release()
 
-	_, err = conn.Exec(context.Background(),
-		`UPDATE stories SET
-			updated_at = CURRENT_TIMESTAMP,
-			status = $1,
-			title = $2,
-			description = $3,
-			sprint_id = $4,
-			edited = true
-			WHERE id = $5`,
-		putReq.Status,
-		putReq.Title,
-		putReq.Description,
-		putReq.SprintID,
-		putReq.ID,
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	tag, err := tx.Exec(context.Background(),
+		`UPDATE comments SET
+			deleted_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND deleted_at IS NULL`,
+		destroyReq.ID,
 	)
 	if err != nil {
 		log.Errorf("conn.Exec failed: %v", err)
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		log.Errorf("destroyComment: no comment with id %d", destroyReq.ID)
+		return InputError{}
+	}
+
+	if err := recordHistory(log, tx, HistoryKindComment, strconv.Itoa(destroyReq.ID), HistoryActionDestroy, nil); err != nil {
+		return err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return err
+	}
 
 	return nil
 }
 
-func PutTask(log *logger.BLogger, putReq PutTaskReq) error {
+func PutStory(log *logger.BLogger, putReq PutStoryReq) (*Story, error) {
+	if putReq.UpdatedAt.IsZero() {
+		log.Error("putStory: UpdatedAt blank")
+		return nil, InputError{}
+	}
+
 	conn, err := database.GetPgxConn()
 	if err != nil {
 		log.Errorf("unable to connect to database: %v", err)
-		return err
+		return nil, err
 	}
 	defer conn.Release()
 
-	_, err = conn.Exec(context.Background(),
-		`UPDATE tasks SET
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	story, err := updateStory(log, tx, putReq)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	return story, nil
+}
+
+// updateStory writes putReq within tx and records the change in the history.
+// A story in the trash cannot be updated; it is reported as an InputError, not a conflict.
+func updateStory(log *logger.BLogger, tx pgx.Tx, putReq PutStoryReq) (*Story, error) {
+	var id, sqid, title, desc, status, sprintID string
+	var cAt, uAt time.Time
+	var edited bool
+	var before Story
+
+	// old locks the row and captures its values before the update, for the history
+	err := tx.QueryRow(context.Background(),
+		`WITH old AS (
+			SELECT id, title, description, status, sprint_id
+				FROM stories
+				WHERE id = $5 AND updated_at = $6 AND deleted_at IS NULL
+				FOR UPDATE
+		)
+		UPDATE stories SET
 			updated_at = CURRENT_TIMESTAMP,
 			status = $1,
 			title = $2,
 			description = $3,
-			story_id = $4,
+			sprint_id = $4,
 			edited = true
-			WHERE id = $5`,
+			FROM old
+			WHERE stories.id = old.id AND stories.updated_at = $6
+			RETURNING
+				stories.id,
+				stories.sqid,
+				stories.created_at,
+				stories.updated_at,
+				stories.title,
+				stories.description,
+				stories.status,
+				stories.sprint_id,
+				stories.edited,
+				old.title,
+				old.description,
+				old.status,
+				old.sprint_id`,
 		putReq.Status,
 		putReq.Title,
 		putReq.Description,
-		putReq.StoryID,
+		putReq.SprintID,
 		putReq.ID,
-	)
+		putReq.UpdatedAt,
+	).Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &sprintID, &edited,
+		&before.Title, &before.Description, &before.Status, &before.SprintID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		current, err := GetStoryByID(log, putReq.ID)
+		if err != nil {
+			return nil, InputError{}
+		}
+		return nil, ConflictError{current}
+	}
 	if err != nil {
 		log.Errorf("conn.Exec failed: %v", err)
-		return err
+		return nil, err
 	}
 
-	return nil
+	story := &Story{id, sqid, cAt, uAt, title, desc, status, sprintID, edited}
+	if err := recordHistory(log, tx, HistoryKindStory, id, HistoryActionUpdate, diffFields(storyHistoryFields(&before), storyHistoryFields(story))); err != nil {
+		return nil, err
+	}
+
+	return story, nil
 }
 
-func GetSprints(log *logger.BLogger) ([]Sprint, error) {
-	conn, err := database.GetPgxConn()
+// PatchStory applies only the fields present in patchReq on top of the story's current values.
+// As with PutStory, the story must not have changed since patchReq.UpdatedAt.
+func PatchStory(log *logger.BLogger, patchReq PatchStoryReq) (*Story, error) {
+	if patchReq.ID == "" || patchReq.UpdatedAt.IsZero() {
+		log.Error("patchStory: ID or UpdatedAt blank")
+		return nil, InputError{}
+	}
+
+	current, err := GetStoryByID(log, patchReq.ID)
 	if err != nil {
-		log.Errorf("unable to connect to database: %v", err)
 		return nil, err
 	}
-	defer conn.Release()
+	if !current.UpdatedAt.Equal(patchReq.UpdatedAt) {
+		return nil, ConflictError{current}
+	}
 
-	rows, err := conn.Query(context.Background(),
+	putReq := PutStoryReq{
+		current.ID,
+		current.Status,
+		current.Title,
+		current.Description,
+		current.SprintID,
+		patchReq.UpdatedAt,
+	}
+	if patchReq.Status != nil {
+		if !storyStatuses[*patchReq.Status] {
+			log.Errorf("patchStory: invalid status %s", *patchReq.Status)
+			return nil, InputError{}
+		}
+		putReq.Status = *patchReq.Status
+	}
+	if patchReq.Title != nil {
+		if *patchReq.Title == "" {
+			log.Error("patchStory: Title blank")
+			return nil, InputError{}
+		}
+		putReq.Title = *patchReq.Title
+	}
+	if patchReq.Description != nil {
+		putReq.Description = *patchReq.Description
+	}
+	if patchReq.SprintID != nil {
+		if _, err := GetSprintByID(log, *patchReq.SprintID); err != nil {
+			log.Errorf("patchStory: no sprint with id %s", *patchReq.SprintID)
+			return nil, InputError{}
+		}
+		putReq.SprintID = *patchReq.SprintID
+	}
+	if err := checkMaxLens(log,
+		lengthCheck{"story_title_max_len", putReq.Title},
+		lengthCheck{"story_desc_max_len", putReq.Description},
+	); err != nil {
+		return nil, err
+	}
+
+	return PutStory(log, putReq)
+}
+
+// DestroyStory moves a story to the trash.
+// A story which still has live tasks is not destroyed; those must be moved or destroyed first.
+// Tag assignments and story relationships are kept so a restore is lossless,
+// and are deleted when the story is purged.
+func DestroyStory(log *logger.BLogger, destroyReq DestroyStoryReq) error {
+	if destroyReq.ID == "" {
+		log.Error("destroyStory: ID blank")
+		return InputError{}
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	var taskCount int
+	err = tx.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM tasks WHERE story_id = $1 AND deleted_at IS NULL`,
+		destroyReq.ID,
+	).Scan(&taskCount)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return err
+	}
+	if taskCount > 0 {
+		log.Errorf("destroyStory: story %s still has %d tasks", destroyReq.ID, taskCount)
+		return InputError{}
+	}
+
+	tag, err := tx.Exec(context.Background(),
+		`UPDATE stories SET
+			deleted_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND deleted_at IS NULL`,
+		destroyReq.ID,
+	)
+	if err != nil {
+		log.Errorf("failed to delete story: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		log.Errorf("destroyStory: no story with id %s", destroyReq.ID)
+		return InputError{}
+	}
+
+	if err := recordHistory(log, tx, HistoryKindStory, destroyReq.ID, HistoryActionDestroy, nil); err != nil {
+		return err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func PutTask(log *logger.BLogger, putReq PutTaskReq) (*UpdatedTask, error) {
+	if putReq.UpdatedAt.IsZero() {
+		log.Error("putTask: UpdatedAt blank")
+		return nil, InputError{}
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	putReq.StoryID = nilIfBlank(putReq.StoryID)
+	putReq.BucketID = nilIfBlank(putReq.BucketID)
+	if err := validateTaskParent(log, conn, putReq.StoryID, putReq.BucketID); err != nil {
+		return nil, err
+	}
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	task, err := updateTask(log, tx, putReq)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// updateTask writes putReq within tx and records the change in the history.
+// The parent in putReq must already be validated.  A task in the trash
+// cannot be updated; it is reported as an InputError, not a conflict.
+func updateTask(log *logger.BLogger, tx pgx.Tx, putReq PutTaskReq) (*UpdatedTask, error) {
+	var id, sqid, title, desc, status string
+	var storyID, bucketID *string
+	var cAt, uAt time.Time
+	var edited, bulkTask bool
+	var before Task
+
+	// old locks the row and captures its values before the update, for the history
+	err := tx.QueryRow(context.Background(),
+		`WITH old AS (
+			SELECT id, title, description, status, story_id, bucket_id
+				FROM tasks
+				WHERE id = $6 AND updated_at = $7 AND deleted_at IS NULL
+				FOR UPDATE
+		)
+		UPDATE tasks SET
+			updated_at = CURRENT_TIMESTAMP,
+			status = $1,
+			title = $2,
+			description = $3,
+			story_id = $4,
+			bucket_id = $5,
+			edited = true
+			FROM old
+			WHERE tasks.id = old.id AND tasks.updated_at = $7
+			RETURNING
+				tasks.id,
+				tasks.sqid,
+				tasks.created_at,
+				tasks.updated_at,
+				tasks.title,
+				tasks.description,
+				tasks.status,
+				tasks.story_id,
+				tasks.bucket_id,
+				tasks.edited,
+				tasks.bulk_task,
+				old.title,
+				old.description,
+				old.status,
+				old.story_id,
+				old.bucket_id`,
+		putReq.Status,
+		putReq.Title,
+		putReq.Description,
+		putReq.StoryID,
+		putReq.BucketID,
+		putReq.ID,
+		putReq.UpdatedAt,
+	).Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &storyID, &bucketID, &edited, &bulkTask,
+		&before.Title, &before.Description, &before.Status, &before.StoryID, &before.BucketID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		current, err := GetTaskByID(log, putReq.ID)
+		if err != nil {
+			return nil, InputError{}
+		}
+		return nil, ConflictError{current}
+	}
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return nil, err
+	}
+
+	task := &Task{id, sqid, cAt, uAt, title, desc, status, storyID, bucketID, edited, bulkTask, nil, nil}
+	if err := recordHistory(log, tx, HistoryKindTask, id, HistoryActionUpdate, diffFields(taskHistoryFields(&before), taskHistoryFields(task))); err != nil {
+		return nil, err
+	}
+
+	// starting a task is allowed while it is blocked, but the client is warned
+	var warnings []string
+	if status == statusDoing && before.Status != statusDoing {
+		blockers, err := queryTaskBlockers(log, tx, id, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, blocker := range blockers {
+			warnings = append(warnings, fmt.Sprintf("blocked by unfinished task %s (%s)", blocker.Sqid, blocker.Title))
+		}
+	}
+
+	return &UpdatedTask{*task, warnings}, nil
+}
+
+// PatchTask applies only the fields present in patchReq on top of the task's current values.
+// As with PutTask, the task must not have changed since patchReq.UpdatedAt.
+func PatchTask(log *logger.BLogger, patchReq PatchTaskReq) (*UpdatedTask, error) {
+	if patchReq.ID == "" || patchReq.UpdatedAt.IsZero() {
+		log.Error("patchTask: ID or UpdatedAt blank")
+		return nil, InputError{}
+	}
+
+	current, err := GetTaskByID(log, patchReq.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, InputError{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !current.UpdatedAt.Equal(patchReq.UpdatedAt) {
+		return nil, ConflictError{current}
+	}
+
+	putReq := PutTaskReq{
+		current.ID,
+		current.Status,
+		current.Title,
+		current.Description,
+		current.StoryID,
+		current.BucketID,
+		patchReq.UpdatedAt,
+	}
+	if patchReq.Status != nil {
+		if !taskStatuses[*patchReq.Status] {
+			log.Errorf("patchTask: invalid status %s", *patchReq.Status)
+			return nil, InputError{}
+		}
+		putReq.Status = *patchReq.Status
+	}
+	if patchReq.Title != nil {
+		if *patchReq.Title == "" {
+			log.Error("patchTask: Title blank")
+			return nil, InputError{}
+		}
+		putReq.Title = *patchReq.Title
+	}
+	if patchReq.Description != nil {
+		putReq.Description = *patchReq.Description
+	}
+	if patchReq.StoryID.Set {
+		putReq.StoryID = patchReq.StoryID.Value
+	}
+	if patchReq.BucketID.Set {
+		putReq.BucketID = patchReq.BucketID.Value
+	}
+	if err := checkMaxLens(log,
+		lengthCheck{"task_title_max_len", putReq.Title},
+		lengthCheck{"task_desc_max_len", putReq.Description},
+	); err != nil {
+		return nil, err
+	}
+
+	return PutTask(log, putReq)
+}
+
+// DestroyTask moves a task to the trash.
+// Its comments are hidden along with it and purged with it.
+func DestroyTask(log *logger.BLogger, destroyReq DestroyTaskReq) error {
+	if destroyReq.ID == "" {
+		log.Error("destroyTask: ID blank")
+		return InputError{}
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	tag, err := tx.Exec(context.Background(),
+		`UPDATE tasks SET
+			deleted_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND deleted_at IS NULL`,
+		destroyReq.ID,
+	)
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		log.Errorf("destroyTask: no task with id %s", destroyReq.ID)
+		return InputError{}
+	}
+
+	if err := recordHistory(log, tx, HistoryKindTask, destroyReq.ID, HistoryActionDestroy, nil); err != nil {
+		return err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetSprints returns a page of sprints
+func GetSprints(log *logger.BLogger, filter SprintFilter) (*Page[Sprint], error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	lq := listQuery{}
+	lq.timeRange("created_at", filter.Created)
+	lq.timeRange("updated_at", filter.Updated)
+
+	rows, err := conn.Query(context.Background(),
 		`SELECT
 				id,
 				created_at,
@@ -520,8 +1214,12 @@ func GetSprints(log *logger.BLogger) ([]Sprint, error) {
 				title,
 				start_date,
 				end_date,
-				edited
-				FROM sprints`,
+				edited,
+				`+sortValueSQL(filter.ListParams, "")+`
+				FROM sprints
+				`+lq.whereSQL(filter.ListParams, "")+`
+				`+orderSQL(filter.ListParams, ""),
+		lq.args...,
 	)
 	if err != nil {
 		log.Errorf("Query failed: %v", err)
@@ -530,39 +1228,62 @@ func GetSprints(log *logger.BLogger) ([]Sprint, error) {
 	defer rows.Close()
 
 	var sprints = []Sprint{}
+	var sortValues, ids []string
 	for rows.Next() {
-		var id, title string
+		var id, title, sortValue string
 		var cAt, uAt, sd, ed time.Time
 		var edited bool
-		rows.Scan(&id, &cAt, &uAt, &title, &sd, &ed, &edited)
+		rows.Scan(&id, &cAt, &uAt, &title, &sd, &ed, &edited, &sortValue)
 		sprints = append(sprints, Sprint{id, cAt, uAt, title, sd.Format("2006-01-02"), ed.Format("2006-01-02"), edited})
+		sortValues = append(sortValues, sortValue)
+		ids = append(ids, id)
 	}
 	if rows.Err() != nil {
 		log.Errorf("Query failed: %v", rows.Err())
 		return nil, rows.Err()
 	}
 
-	return sprints, nil
+	sprints, cursor := nextCursor(filter.ListParams, sprints, sortValues, ids)
+	return &Page[Sprint]{sprints, cursor}, nil
 }
 
-func CreateSprint(log *logger.BLogger, createReq CreateSprintReq) (*Sprint, error) {
-	startDate, err := time.Parse("2006-01-02", createReq.StartDate)
+func GetSprintByID(log *logger.BLogger, sprintID string) (*Sprint, error) {
+	conn, err := database.GetPgxConn()
 	if err != nil {
-		log.Infof("invalid sprint start date: %v", createReq.StartDate)
-		return nil, InputError{}
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
 	}
-	endDate, err := time.Parse("2006-01-02", createReq.EndDate)
+	defer conn.Release()
+
+	var id, title string
+	var cAt, uAt, sd, ed time.Time
+	var edited bool
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT
+				id,
+				created_at,
+				updated_at,
+				title,
+				start_date,
+				end_date,
+				edited
+				FROM sprints
+				WHERE id = $1`,
+		sprintID,
+	).Scan(&id, &cAt, &uAt, &title, &sd, &ed, &edited)
 	if err != nil {
-		log.Infof("invalid sprint end date: %v", createReq.EndDate)
-		return nil, InputError{}
+		log.Errorf("Query failed: %v", err)
+		return nil, err
 	}
-	if !startDate.Before(endDate) {
-		log.Infof(
-			"invalid sprint range: start=%s end=%s",
-			createReq.StartDate,
-			createReq.EndDate,
-		)
-		return nil, InputError{}
+
+	return &Sprint{id, cAt, uAt, title, sd.Format("2006-01-02"), ed.Format("2006-01-02"), edited}, nil
+}
+
+func CreateSprint(log *logger.BLogger, createReq CreateSprintReq) (*Sprint, error) {
+	startDate, endDate, err := parseSprintDates(log, createReq.StartDate, createReq.EndDate)
+	if err != nil {
+		return nil, err
 	}
 
 	conn, err := database.GetPgxConn()
@@ -572,6 +1293,12 @@ func CreateSprint(log *logger.BLogger, createReq CreateSprintReq) (*Sprint, erro
 	}
 	defer conn.Release()
 
+	if !createReq.AllowOverlap {
+		if err := checkSprintOverlap(log, conn, startDate, endDate, ""); err != nil {
+			return nil, err
+		}
+	}
+
 	var id, title string
 	var cAt, uAt, sd, ed time.Time
 	var edited bool
@@ -607,7 +1334,19 @@ func CreateSprint(log *logger.BLogger, createReq CreateSprintReq) (*Sprint, erro
 	return &Sprint{id, cAt, uAt, title, sd.Format("2006-01-02"), ed.Format("2006-01-02"), edited}, nil
 }
 
-func GetStories(log *logger.BLogger) ([]Story, error) {
+func PutSprint(log *logger.BLogger, putReq PutSprintReq) (*Sprint, error) {
+	if putReq.Title == "" || putReq.UpdatedAt.IsZero() {
+		log.Error("putSprint: Title or UpdatedAt blank")
+		return nil, InputError{}
+	}
+	startDate, endDate, err := parseSprintDates(log, putReq.StartDate, putReq.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkMaxLens(log, lengthCheck{"sprint_title_max_len", putReq.Title}); err != nil {
+		return nil, err
+	}
+
 	conn, err := database.GetPgxConn()
 	if err != nil {
 		log.Errorf("unable to connect to database: %v", err)
@@ -615,6 +1354,135 @@ func GetStories(log *logger.BLogger) ([]Story, error) {
 	}
 	defer conn.Release()
 
+	if !putReq.AllowOverlap {
+		if err := checkSprintOverlap(log, conn, startDate, endDate, putReq.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	var id, title string
+	var cAt, uAt, sd, ed time.Time
+	var edited bool
+
+	err = conn.QueryRow(context.Background(),
+		`UPDATE sprints SET
+			updated_at = CURRENT_TIMESTAMP,
+			title = $1,
+			start_date = $2,
+			end_date = $3,
+			edited = true
+			WHERE id = $4 AND updated_at = $5
+			RETURNING
+				id,
+				created_at,
+				updated_at,
+				title,
+				start_date,
+				end_date,
+				edited`,
+		putReq.Title,
+		startDate.Format("2006-01-02"),
+		endDate.Format("2006-01-02"),
+		putReq.ID,
+		putReq.UpdatedAt,
+	).Scan(&id, &cAt, &uAt, &title, &sd, &ed, &edited)
+	if errors.Is(err, pgx.ErrNoRows) {
+		current, err := GetSprintByID(log, putReq.ID)
+		if err != nil {
+			return nil, InputError{}
+		}
+		return nil, ConflictError{current}
+	}
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return nil, err
+	}
+
+	return &Sprint{id, cAt, uAt, title, sd.Format("2006-01-02"), ed.Format("2006-01-02"), edited}, nil
+}
+
+// DestroySprint deletes a sprint.
+// A sprint which still has stories is not destroyed; those must be moved or destroyed first.
+func DestroySprint(log *logger.BLogger, destroyReq DestroySprintReq) error {
+	if destroyReq.ID == "" {
+		log.Error("destroySprint: ID blank")
+		return InputError{}
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	var storyCount int
+	err = tx.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM stories WHERE sprint_id = $1`,
+		destroyReq.ID,
+	).Scan(&storyCount)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return err
+	}
+	if storyCount > 0 {
+		log.Errorf("destroySprint: sprint %s still has %d stories", destroyReq.ID, storyCount)
+		return InputError{}
+	}
+
+	tag, err := tx.Exec(context.Background(),
+		`DELETE FROM sprints WHERE id = $1`,
+		destroyReq.ID,
+	)
+	if err != nil {
+		log.Errorf("failed to delete sprint: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		log.Errorf("destroySprint: no sprint with id %s", destroyReq.ID)
+		return InputError{}
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetStories returns a page of the stories which are not in the trash
+func GetStories(log *logger.BLogger, filter StoryFilter) (*Page[Story], error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	lq := listQuery{}
+	lq.where("deleted_at IS NULL")
+	if len(filter.Statuses) > 0 {
+		lq.where("status::text = ANY(" + lq.arg(filter.Statuses) + ")")
+	}
+	if filter.SprintID != nil {
+		lq.where("sprint_id = " + lq.arg(*filter.SprintID))
+	}
+	if filter.TagID != nil {
+		lq.where(`id IN (SELECT story_id FROM tag_assignments
+				WHERE tag_id IN (` + tagSubtreeSQL(lq.arg(*filter.TagID)) + `))`)
+	}
+	lq.timeRange("created_at", filter.Created)
+	lq.timeRange("updated_at", filter.Updated)
+
 	rows, err := conn.Query(context.Background(),
 		`SELECT
 				id,
@@ -625,8 +1493,12 @@ func GetStories(log *logger.BLogger) ([]Story, error) {
 				description,
 				status,
 				sprint_id,
-				edited
-				FROM stories`,
+				edited,
+				`+sortValueSQL(filter.ListParams, "")+`
+				FROM stories
+				`+lq.whereSQL(filter.ListParams, "")+`
+				`+orderSQL(filter.ListParams, ""),
+		lq.args...,
 	)
 	if err != nil {
 		log.Errorf("Query failed: %v", err)
@@ -635,12 +1507,15 @@ func GetStories(log *logger.BLogger) ([]Story, error) {
 	defer rows.Close()
 
 	var stories []Story = []Story{}
+	var sortValues, ids []string
 	for rows.Next() {
-		var id, sqid, title, desc, status, sID string
+		var id, sqid, title, desc, status, sID, sortValue string
 		var cAt, uAt time.Time
 		var edited bool
-		rows.Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &sID, &edited)
+		rows.Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &sID, &edited, &sortValue)
 		stories = append(stories, Story{id, sqid, cAt, uAt, title, desc, status, sID, edited})
+		sortValues = append(sortValues, sortValue)
+		ids = append(ids, id)
 	}
 
 	if rows.Err() != nil {
@@ -648,7 +1523,8 @@ func GetStories(log *logger.BLogger) ([]Story, error) {
 		return nil, rows.Err()
 	}
 
-	return stories, nil
+	stories, cursor := nextCursor(filter.ListParams, stories, sortValues, ids)
+	return &Page[Story]{stories, cursor}, nil
 }
 
 func CreateStory(log *logger.BLogger, s *sqids.Sqids, createReq CreateStoryReq) (*Story, error) {
@@ -663,10 +1539,17 @@ func CreateStory(log *logger.BLogger, s *sqids.Sqids, createReq CreateStoryReq)
 	var cAt, uAt time.Time
 	var edited bool
 
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
 	// generate the sqid
 	sq, _ := s.Encode([]uint64{uint64(time.Now().UnixNano())})
 
-	err = conn.QueryRow(context.Background(),
+	err = tx.QueryRow(context.Background(),
 		`INSERT INTO stories (
 				updated_at,
 				title,
@@ -695,14 +1578,26 @@ func CreateStory(log *logger.BLogger, s *sqids.Sqids, createReq CreateStoryReq)
 		sq,
 	).Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &sprintID, &edited)
 	if err != nil {
-		log.Errorf("conn.Exec failed: %v", err)
+		log.Errorf("conn.Exec failed: %v", err)
+		return nil, err
+	}
+
+	story := &Story{id, sqid, cAt, uAt, title, desc, status, sprintID, edited}
+	if err := recordHistory(log, tx, HistoryKindStory, id, HistoryActionCreate, diffFields(nil, storyHistoryFields(story))); err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
 		return nil, err
 	}
 
-	return &Story{id, sqid, cAt, uAt, title, desc, status, sprintID, edited}, nil
+	return story, nil
 }
 
-func GetTags(log *logger.BLogger) ([]Tag, error) {
+// GetTags returns a page of tags
+func GetTags(log *logger.BLogger, filter TagFilter) (*Page[Tag], error) {
 	conn, err := database.GetPgxConn()
 	if err != nil {
 		log.Errorf("unable to connect to database: %v", err)
@@ -710,6 +1605,10 @@ func GetTags(log *logger.BLogger) ([]Tag, error) {
 	}
 	defer conn.Release()
 
+	lq := listQuery{}
+	lq.timeRange("created_at", filter.Created)
+	lq.timeRange("updated_at", filter.Updated)
+
 	rows, err := conn.Query(context.Background(),
 		`SELECT
 				id,
@@ -717,9 +1616,14 @@ func GetTags(log *logger.BLogger) ([]Tag, error) {
 				updated_at,
 				title,
 				description,
+				parent_id,
 				is_parent,
-				edited
-				FROM tags`,
+				edited,
+				`+sortValueSQL(filter.ListParams, "")+`
+				FROM tags
+				`+lq.whereSQL(filter.ListParams, "")+`
+				`+orderSQL(filter.ListParams, ""),
+		lq.args...,
 	)
 	if err != nil {
 		log.Errorf("Query failed: %v", err)
@@ -728,19 +1632,59 @@ func GetTags(log *logger.BLogger) ([]Tag, error) {
 	defer rows.Close()
 
 	var tags = []Tag{}
+	var sortValues, ids []string
 	for rows.Next() {
-		var id, title, desc string
+		var id, title, desc, sortValue string
 		var cAt, uAt time.Time
+		var parentID *string
 		var isParent, edited bool
-		rows.Scan(&id, &cAt, &uAt, &title, &desc, &isParent, &edited)
-		tags = append(tags, Tag{id, cAt, uAt, title, desc, isParent, edited})
+		rows.Scan(&id, &cAt, &uAt, &title, &desc, &parentID, &isParent, &edited, &sortValue)
+		tags = append(tags, Tag{id, cAt, uAt, title, desc, parentID, isParent, edited})
+		sortValues = append(sortValues, sortValue)
+		ids = append(ids, id)
 	}
 	if rows.Err() != nil {
 		log.Errorf("Query failed: %v", rows.Err())
 		return nil, rows.Err()
 	}
 
-	return tags, nil
+	tags, cursor := nextCursor(filter.ListParams, tags, sortValues, ids)
+	return &Page[Tag]{tags, cursor}, nil
+}
+
+func GetTagByID(log *logger.BLogger, tagID string) (*Tag, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	var id, title, desc string
+	var cAt, uAt time.Time
+	var parentID *string
+	var isParent, edited bool
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT
+				id,
+				created_at,
+				updated_at,
+				title,
+				description,
+				parent_id,
+				is_parent,
+				edited
+				FROM tags
+				WHERE id = $1`,
+		tagID,
+	).Scan(&id, &cAt, &uAt, &title, &desc, &parentID, &isParent, &edited)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+
+	return &Tag{id, cAt, uAt, title, desc, parentID, isParent, edited}, nil
 }
 
 func GetTagAssignments(log *logger.BLogger) ([]TagAssignment, error) {
@@ -889,39 +1833,339 @@ func CreateTag(log *logger.BLogger, createReq CreateTagReq) (*Tag, error) {
 	}
 	defer conn.Release()
 
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	if createReq.ParentID != nil {
+		if err := lockTagsForReparent(log, tx); err != nil {
+			return nil, err
+		}
+		if err := checkTagParent(log, tx, "", *createReq.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	var id, title, desc string
+	var cAt, uAt time.Time
+	var parentID *string
+	var isParent, edited bool
+
+	err = tx.QueryRow(context.Background(),
+		`INSERT INTO tags (
+				updated_at,
+				title,
+				description,
+				parent_id
+			) VALUES (
+				CURRENT_TIMESTAMP,
+				$1,
+				$2,
+				$3
+			) RETURNING
+				id,
+				created_at,
+				updated_at,
+				title,
+				description,
+				parent_id,
+				is_parent,
+				edited`,
+		createReq.Title,
+		createReq.Description,
+		createReq.ParentID,
+	).Scan(&id, &cAt, &uAt, &title, &desc, &parentID, &isParent, &edited)
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return nil, err
+	}
+
+	if parentID != nil {
+		if err := refreshTagIsParent(log, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	return &Tag{id, cAt, uAt, title, desc, parentID, isParent, edited}, nil
+}
+
+func PutTag(log *logger.BLogger, putReq PutTagReq) (*Tag, error) {
+	if putReq.Title == "" || putReq.Description == "" || putReq.UpdatedAt.IsZero() {
+		log.Error("putTag: Title, Description or UpdatedAt blank")
+		return nil, InputError{}
+	}
+	if err := checkMaxLens(log,
+		lengthCheck{"tag_title_max_len", putReq.Title},
+		lengthCheck{"tag_desc_max_len", putReq.Description},
+	); err != nil {
+		return nil, err
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	// the lock is taken even without a parent, since the tag may be leaving one
+	if err := lockTagsForReparent(log, tx); err != nil {
+		return nil, err
+	}
+	if putReq.ParentID != nil {
+		if err := checkTagParent(log, tx, putReq.ID, *putReq.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
 	var id, title, desc string
 	var cAt, uAt time.Time
+	var parentID *string
 	var isParent, edited bool
 
+	err = tx.QueryRow(context.Background(),
+		`UPDATE tags SET
+			updated_at = CURRENT_TIMESTAMP,
+			title = $1,
+			description = $2,
+			parent_id = $5,
+			edited = true
+			WHERE id = $3 AND updated_at = $4
+			RETURNING
+				id,
+				created_at,
+				updated_at,
+				title,
+				description,
+				parent_id,
+				is_parent,
+				edited`,
+		putReq.Title,
+		putReq.Description,
+		putReq.ID,
+		putReq.UpdatedAt,
+		putReq.ParentID,
+	).Scan(&id, &cAt, &uAt, &title, &desc, &parentID, &isParent, &edited)
+	if errors.Is(err, pgx.ErrNoRows) {
+		current, err := GetTagByID(log, putReq.ID)
+		if err != nil {
+			return nil, InputError{}
+		}
+		return nil, ConflictError{current}
+	}
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return nil, err
+	}
+
+	if err := refreshTagIsParent(log, tx); err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	return &Tag{id, cAt, uAt, title, desc, parentID, isParent, edited}, nil
+}
+
+// DestroyTag deletes a tag along with every story, bucket and task assignment of it.
+// Child tags are moved up to the tag's own parent.
+func DestroyTag(log *logger.BLogger, destroyReq DestroyTagReq) error {
+	if destroyReq.ID == "" {
+		log.Error("destroyTag: ID blank")
+		return InputError{}
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	_, err = tx.Exec(context.Background(),
+		`DELETE FROM tag_assignments WHERE tag_id = $1`,
+		destroyReq.ID,
+	)
+	if err != nil {
+		log.Errorf("failed to delete tag assignments: %v", err)
+		return err
+	}
+
+	_, err = tx.Exec(context.Background(),
+		`DELETE FROM bucket_tag_assignments WHERE tag_id = $1`,
+		destroyReq.ID,
+	)
+	if err != nil {
+		log.Errorf("failed to delete bucket tag assignments: %v", err)
+		return err
+	}
+
+	_, err = tx.Exec(context.Background(),
+		`DELETE FROM task_tag_assignments WHERE tag_id = $1`,
+		destroyReq.ID,
+	)
+	if err != nil {
+		log.Errorf("failed to delete task tag assignments: %v", err)
+		return err
+	}
+
+	if err := lockTagsForReparent(log, tx); err != nil {
+		return err
+	}
+	_, err = tx.Exec(context.Background(),
+		`UPDATE tags SET parent_id = (SELECT parent_id FROM tags WHERE id = $1)
+			WHERE parent_id = $1`,
+		destroyReq.ID,
+	)
+	if err != nil {
+		log.Errorf("failed to reparent child tags: %v", err)
+		return err
+	}
+
+	tag, err := tx.Exec(context.Background(),
+		`DELETE FROM tags WHERE id = $1`,
+		destroyReq.ID,
+	)
+	if err != nil {
+		log.Errorf("failed to delete tag: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		log.Errorf("destroyTag: no tag with id %s", destroyReq.ID)
+		return InputError{}
+	}
+
+	if err := refreshTagIsParent(log, tx); err != nil {
+		return err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetBuckets returns the buckets which are not in the trash.  If tagID is not
+// blank, only buckets tagged with it or with any of its descendants are returned.
+func GetBuckets(log *logger.BLogger, tagID string) ([]Bucket, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	query := `SELECT
+				id,
+				sqid,
+				created_at,
+				updated_at,
+				title,
+				description,
+				status,
+				edited
+				FROM buckets
+				WHERE deleted_at IS NULL`
+	var args []interface{}
+	if tagID != "" {
+		query += `
+				AND id IN (SELECT bucket_id FROM bucket_tag_assignments
+					WHERE tag_id IN (` + tagSubtreeSQL("$1") + `))`
+		args = append(args, tagID)
+	}
+
+	rows, err := conn.Query(context.Background(), query, args...)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buckets = []Bucket{}
+	for rows.Next() {
+		var id, sqid, title, desc, status string
+		var cAt, uAt time.Time
+		var edited bool
+		rows.Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &edited)
+		buckets = append(buckets, Bucket{id, sqid, cAt, uAt, title, desc, status, edited})
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	return buckets, nil
+}
+
+func GetBucketBySQID(log *logger.BLogger, bucketSQID string) (*Bucket, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	var id, sqid, title, desc, status string
+	var cAt, uAt time.Time
+	var edited bool
+
 	err = conn.QueryRow(context.Background(),
-		`INSERT INTO tags (
-				updated_at,
-				title,
-				description
-			) VALUES (
-				CURRENT_TIMESTAMP,
-				$1,
-				$2
-			) RETURNING
+		`SELECT
 				id,
+				sqid,
 				created_at,
 				updated_at,
 				title,
 				description,
-				is_parent,
-				edited`,
-		createReq.Title,
-		createReq.Description,
-	).Scan(&id, &cAt, &uAt, &title, &desc, &isParent, &edited)
+				status,
+				edited
+				FROM buckets
+				WHERE sqid = $1 AND deleted_at IS NULL`,
+		bucketSQID,
+	).Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &edited)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Errorf("no live bucket with sqid %s", bucketSQID)
+		return nil, InputError{}
+	}
 	if err != nil {
-		log.Errorf("conn.Exec failed: %v", err)
+		log.Errorf("Query failed: %v", err)
 		return nil, err
 	}
 
-	return &Tag{id, cAt, uAt, title, desc, isParent, edited}, nil
+	return &Bucket{id, sqid, cAt, uAt, title, desc, status, edited}, nil
 }
 
-func GetBuckets(log *logger.BLogger) ([]Bucket, error) {
+func GetBucketByID(log *logger.BLogger, bucketID string) (*Bucket, error) {
 	conn, err := database.GetPgxConn()
 	if err != nil {
 		log.Errorf("unable to connect to database: %v", err)
@@ -929,7 +2173,11 @@ func GetBuckets(log *logger.BLogger) ([]Bucket, error) {
 	}
 	defer conn.Release()
 
-	rows, err := conn.Query(context.Background(),
+	var id, sqid, title, desc, status string
+	var cAt, uAt time.Time
+	var edited bool
+
+	err = conn.QueryRow(context.Background(),
 		`SELECT
 				id,
 				sqid,
@@ -939,28 +2187,20 @@ func GetBuckets(log *logger.BLogger) ([]Bucket, error) {
 				description,
 				status,
 				edited
-				FROM buckets`,
-	)
+				FROM buckets
+				WHERE id = $1 AND deleted_at IS NULL`,
+		bucketID,
+	).Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &edited)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Errorf("no live bucket with id %s", bucketID)
+		return nil, InputError{}
+	}
 	if err != nil {
 		log.Errorf("Query failed: %v", err)
 		return nil, err
 	}
-	defer rows.Close()
-
-	var buckets = []Bucket{}
-	for rows.Next() {
-		var id, sqid, title, desc, status string
-		var cAt, uAt time.Time
-		var edited bool
-		rows.Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &edited)
-		buckets = append(buckets, Bucket{id, sqid, cAt, uAt, title, desc, status, edited})
-	}
-	if rows.Err() != nil {
-		log.Errorf("Query failed: %v", rows.Err())
-		return nil, rows.Err()
-	}
 
-	return buckets, nil
+	return &Bucket{id, sqid, cAt, uAt, title, desc, status, edited}, nil
 }
 
 func CreateBucket(log *logger.BLogger, s *sqids.Sqids, createReq CreateBucketReq) (*Bucket, error) {
@@ -1015,6 +2255,153 @@ func CreateBucket(log *logger.BLogger, s *sqids.Sqids, createReq CreateBucketReq
 	return &Bucket{id, sqid, cAt, uAt, title, desc, status, edited}, nil
 }
 
+// PutBucket updates a bucket, rejecting status changes not allowed by bucketStatusTransitions
+func PutBucket(log *logger.BLogger, putReq PutBucketReq) (*Bucket, error) {
+	if putReq.Title == "" || putReq.UpdatedAt.IsZero() {
+		log.Error("putBucket: Title or UpdatedAt blank")
+		return nil, InputError{}
+	}
+	if err := checkMaxLens(log,
+		lengthCheck{"bucket_title_max_len", putReq.Title},
+		lengthCheck{"bucket_desc_max_len", putReq.Description},
+	); err != nil {
+		return nil, err
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	var id, sqid, title, desc, status string
+	var cAt, uAt time.Time
+	var edited bool
+
+	err = tx.QueryRow(context.Background(),
+		`SELECT
+				id,
+				sqid,
+				created_at,
+				updated_at,
+				title,
+				description,
+				status,
+				edited
+				FROM buckets
+				WHERE id = $1 AND deleted_at IS NULL
+				FOR UPDATE`,
+		putReq.ID,
+	).Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &edited)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Errorf("putBucket: no bucket with id %s", putReq.ID)
+		return nil, InputError{}
+	}
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+
+	if !uAt.Equal(putReq.UpdatedAt) {
+		return nil, ConflictError{&Bucket{id, sqid, cAt, uAt, title, desc, status, edited}}
+	}
+
+	if !validBucketStatusTransition(status, putReq.Status) {
+		log.Errorf("putBucket: invalid status transition %s -> %s", status, putReq.Status)
+		return nil, InputError{}
+	}
+
+	err = tx.QueryRow(context.Background(),
+		`UPDATE buckets SET
+			updated_at = now(),
+			title = $1,
+			description = $2,
+			status = $3,
+			edited = true
+			WHERE id = $4
+			RETURNING
+				id,
+				sqid,
+				created_at,
+				updated_at,
+				title,
+				description,
+				status,
+				edited`,
+		putReq.Title,
+		putReq.Description,
+		putReq.Status,
+		putReq.ID,
+	).Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &edited)
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return nil, err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	return &Bucket{id, sqid, cAt, uAt, title, desc, status, edited}, nil
+}
+
+// DestroyBucket moves a bucket to the trash.
+// Its tasks keep their bucket_id and tag assignments are kept so a restore
+// is lossless; tasks are detached and assignments deleted when the bucket is purged.
+func DestroyBucket(log *logger.BLogger, destroyReq DestroyBucketReq) error {
+	if destroyReq.ID == "" {
+		log.Error("destroyBucket: ID blank")
+		return InputError{}
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	tag, err := tx.Exec(context.Background(),
+		`UPDATE buckets SET
+			deleted_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND deleted_at IS NULL`,
+		destroyReq.ID,
+	)
+	if err != nil {
+		log.Errorf("failed to delete bucket: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		log.Errorf("destroyBucket: no bucket with id %s", destroyReq.ID)
+		return InputError{}
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
 func GetBucketTagAssignments(log *logger.BLogger) ([]BucketTagAssignment, error) {
 	conn, err := database.GetPgxConn()
 	if err != nil {
@@ -1100,11 +2487,22 @@ func DestroyBucketTagAssignmentByID(log *logger.BLogger, destroyReq DestroyBucke
 	return nil
 }
 
+// CreateStoryRelationship relates story A to story B.
+// A BLOCKS or PARENT_OF relationship which would close a cycle
+// among the BLOCKS and PARENT_OF relationships is rejected.
 func CreateStoryRelationship(log *logger.BLogger, createReq CreateStoryRelationshipReq) (*StoryRelationship, error) {
 	if createReq.StoryIDA == "" || createReq.StoryIDB == "" || createReq.Relation == "" {
 		log.Error("createStoryRelationship: parameter(s) blank")
 		return nil, InputError{}
 	}
+	if !storyRelations[createReq.Relation] {
+		log.Errorf("createStoryRelationship: invalid relation %s", createReq.Relation)
+		return nil, InputError{}
+	}
+	if createReq.StoryIDA == createReq.StoryIDB {
+		log.Error("createStoryRelationship: a story cannot be related to itself")
+		return nil, InputError{}
+	}
 
 	conn, err := database.GetPgxConn()
 	if err != nil {
@@ -1113,11 +2511,30 @@ func CreateStoryRelationship(log *logger.BLogger, createReq CreateStoryRelations
 	}
 	defer conn.Release()
 
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	if dependencyRelations[createReq.Relation] {
+		// serialize with other relationship creation so two edges cannot close a cycle together
+		_, err = tx.Exec(context.Background(), `LOCK TABLE story_relationships IN SHARE ROW EXCLUSIVE MODE`)
+		if err != nil {
+			log.Errorf("failed to lock story_relationships: %v", err)
+			return nil, err
+		}
+		if err := checkStoryDependencyCycle(log, tx, createReq.StoryIDA, createReq.StoryIDB); err != nil {
+			return nil, err
+		}
+	}
+
 	var id int
 	var storyIDA, storyIDB, relation string
 	var cAt time.Time
 
-	err = conn.QueryRow(context.Background(),
+	err = tx.QueryRow(context.Background(),
 		`INSERT INTO story_relationships (
 				story_id_a,
 				story_id_b,
@@ -1141,6 +2558,12 @@ func CreateStoryRelationship(log *logger.BLogger, createReq CreateStoryRelations
 		return nil, err
 	}
 
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
 	return &StoryRelationship{id, cAt, storyIDA, storyIDB, relation}, nil
 }
 
@@ -1322,6 +2745,111 @@ func BatchUpdateSessionsLastAccessed(log *logger.BLogger, updates map[string]tim
 	return nil
 }
 
+// GetUnexpiredSessionRecords returns every session created after createdAfter.
+// Used to rehydrate the session manager on startup.
+func GetUnexpiredSessionRecords(log *logger.BLogger, createdAfter time.Time) ([]SessionRecord, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT
+				id,
+				created_at,
+				updated_at,
+				caller_id,
+				session_id,
+				session_created_at,
+				session_last_accessed
+				FROM sessions
+				WHERE session_created_at > $1`,
+		createdAfter,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessionRecords = []SessionRecord{}
+	for rows.Next() {
+		var id, callerID, sessionID string
+		var cAt, uAt, sCAt, sLA time.Time
+		rows.Scan(&id, &cAt, &uAt, &callerID, &sessionID, &sCAt, &sLA)
+		sessionRecords = append(sessionRecords, SessionRecord{id, cAt, uAt, callerID, sessionID, sCAt, sLA})
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	return sessionRecords, nil
+}
+
+// GetSessionRecordBySessionID returns (nil, nil) if no such session exists
+func GetSessionRecordBySessionID(log *logger.BLogger, sessionID string) (*SessionRecord, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	var id, callerID, sID string
+	var cAt, uAt, sCAt, sLA time.Time
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT
+				id,
+				created_at,
+				updated_at,
+				caller_id,
+				session_id,
+				session_created_at,
+				session_last_accessed
+				FROM sessions
+				WHERE session_id = $1`,
+		sessionID,
+	).Scan(&id, &cAt, &uAt, &callerID, &sID, &sCAt, &sLA)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+
+	return &SessionRecord{id, cAt, uAt, callerID, sID, sCAt, sLA}, nil
+}
+
+// DestroyExpiredSessionRecords deletes every session created on or before createdBefore
+// and returns the number of rows removed
+func DestroyExpiredSessionRecords(log *logger.BLogger, createdBefore time.Time) (int64, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return 0, err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(context.Background(),
+		`DELETE FROM sessions
+				WHERE
+				session_created_at <= $1
+			;`,
+		createdBefore,
+	)
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
 // TODO: still undecided but may be better to split the upload and artifact logic into 2 db calls
 func CreateUploadWithArtifacts(log *logger.BLogger, callerID string, createReq CreateUploadWithArtifactsReq) error {
 	if createReq.UploadType == "" || len(createReq.Artifacts) == 0 {
@@ -0,0 +1,238 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/bschlaman/todo-app/database"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// DefaultVelocitySprints is how many sprints of velocity a sprint report includes by default
+const DefaultVelocitySprints = 5
+
+// GetSprintReport returns the burndown of a sprint, the velocity of the
+// velocitySprints sprints up to and including it, and its carried over stories
+func GetSprintReport(log *logger.BLogger, sprintID string, velocitySprints int) (*SprintReport, error) {
+	if sprintID == "" || velocitySprints < 1 {
+		log.Error("getSprintReport: sprintID blank or velocitySprints < 1")
+		return nil, InputError{}
+	}
+
+	sprint, err := GetSprintByID(log, sprintID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, InputError{}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	burndown, err := getSprintBurndown(log, conn, sprint, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	velocity, err := getSprintVelocity(log, conn, sprint, velocitySprints)
+	if err != nil {
+		return nil, err
+	}
+	carriedOver, err := getCarriedOverStories(log, conn, sprint.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SprintReport{*sprint, burndown, velocity, carriedOver}, nil
+}
+
+// getSprintBurndown counts the sprint's tasks at the end of each day of the sprint, up to now.
+// A task's status on a given day comes from its status history; tasks with no
+// recorded history are taken to have reached their current status at updated_at.
+func getSprintBurndown(log *logger.BLogger, conn *pgxpool.Conn, sprint *Sprint, now time.Time) ([]BurndownPoint, error) {
+	rows, err := conn.Query(context.Background(),
+		`SELECT
+				t.id::text,
+				t.created_at,
+				t.updated_at,
+				t.status,
+				h.created_at,
+				h.changes->'status'->>'new'
+				FROM tasks t
+				JOIN stories s ON t.story_id = s.id
+				LEFT JOIN entity_history h
+					ON h.entity_kind = 'task'
+					AND h.entity_id = t.id::text
+					AND h.changes ? 'status'
+				WHERE s.sprint_id = $1
+				AND s.deleted_at IS NULL
+				AND t.deleted_at IS NULL
+				ORDER BY t.id, h.id`,
+		sprint.ID,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	type sprintTask struct {
+		createdAt, updatedAt time.Time
+		currentStatus        string
+		transitions          []statusTransition
+	}
+	var taskIDs []string
+	tasks := map[string]*sprintTask{}
+	for rows.Next() {
+		var id, status string
+		var cAt, uAt time.Time
+		var hAt *time.Time
+		var newStatus *string
+		rows.Scan(&id, &cAt, &uAt, &status, &hAt, &newStatus)
+		t, ok := tasks[id]
+		if !ok {
+			t = &sprintTask{createdAt: cAt, updatedAt: uAt, currentStatus: status}
+			tasks[id] = t
+			taskIDs = append(taskIDs, id)
+		}
+		if hAt != nil && newStatus != nil {
+			t.transitions = append(t.transitions, statusTransition{*hAt, *newStatus})
+		}
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	// doneAt reports whether a task was DONE as of the given time
+	doneAt := func(t *sprintTask, at time.Time) bool {
+		if len(t.transitions) == 0 {
+			return t.currentStatus == statusDone && t.updatedAt.Before(at)
+		}
+		status := ""
+		for _, tr := range t.transitions {
+			if !tr.at.Before(at) {
+				break
+			}
+			status = tr.status
+		}
+		return status == statusDone
+	}
+
+	startDate, _ := time.Parse("2006-01-02", sprint.StartDate)
+	endDate, _ := time.Parse("2006-01-02", sprint.EndDate)
+
+	var burndown = []BurndownPoint{}
+	for day := startDate; !day.After(endDate) && day.Before(now); day = day.AddDate(0, 0, 1) {
+		dayEnd := day.AddDate(0, 0, 1)
+		point := BurndownPoint{Date: day.Format("2006-01-02")}
+		for _, id := range taskIDs {
+			t := tasks[id]
+			if !t.createdAt.Before(dayEnd) {
+				continue
+			}
+			point.Total++
+			if doneAt(t, dayEnd) {
+				point.Completed++
+			}
+		}
+		point.Remaining = point.Total - point.Completed
+		burndown = append(burndown, point)
+	}
+
+	return burndown, nil
+}
+
+// getSprintVelocity returns the finished work of the last n sprints
+// which started no later than sprint, oldest first
+func getSprintVelocity(log *logger.BLogger, conn *pgxpool.Conn, sprint *Sprint, n int) ([]SprintVelocity, error) {
+	rows, err := conn.Query(context.Background(),
+		`SELECT
+				sp.id,
+				sp.title,
+				(SELECT COUNT(*) FROM tasks t JOIN stories s ON t.story_id = s.id
+					WHERE s.sprint_id = sp.id AND s.deleted_at IS NULL AND t.deleted_at IS NULL),
+				(SELECT COUNT(*) FROM tasks t JOIN stories s ON t.story_id = s.id
+					WHERE s.sprint_id = sp.id AND s.deleted_at IS NULL AND t.deleted_at IS NULL
+					AND t.status = 'DONE'),
+				(SELECT COUNT(*) FROM stories s
+					WHERE s.sprint_id = sp.id AND s.deleted_at IS NULL),
+				(SELECT COUNT(*) FROM stories s
+					WHERE s.sprint_id = sp.id AND s.deleted_at IS NULL AND s.status = 'DONE')
+				FROM sprints sp
+				WHERE sp.start_date <= $1::date
+				ORDER BY sp.start_date DESC
+				LIMIT $2`,
+		sprint.StartDate,
+		n,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var velocity = []SprintVelocity{}
+	for rows.Next() {
+		var id, title string
+		var totalTasks, completedTasks, totalStories, completedStories int
+		rows.Scan(&id, &title, &totalTasks, &completedTasks, &totalStories, &completedStories)
+		// prepend so that the oldest sprint comes first
+		velocity = append([]SprintVelocity{{id, title, totalTasks, completedTasks, totalStories, completedStories}}, velocity...)
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	return velocity, nil
+}
+
+// getCarriedOverStories returns the stories of a sprint which were
+// CONTINUED_BY a story in another sprint
+func getCarriedOverStories(log *logger.BLogger, conn *pgxpool.Conn, sprintID string) ([]CarriedOverStory, error) {
+	rows, err := conn.Query(context.Background(),
+		`SELECT
+				a.id,
+				a.sqid,
+				a.title,
+				b.id,
+				b.sqid,
+				b.sprint_id
+				FROM story_relationships r
+				JOIN stories a ON r.story_id_a = a.id
+				JOIN stories b ON r.story_id_b = b.id
+				WHERE r.relation = 'CONTINUED_BY'
+				AND a.sprint_id = $1
+				AND b.sprint_id <> $1
+				AND a.deleted_at IS NULL
+				AND b.deleted_at IS NULL
+				ORDER BY r.created_at`,
+		sprintID,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var carriedOver = []CarriedOverStory{}
+	for rows.Next() {
+		var id, sqid, title, continuedByID, continuedBySqid, continuedBySprintID string
+		rows.Scan(&id, &sqid, &title, &continuedByID, &continuedBySqid, &continuedBySprintID)
+		carriedOver = append(carriedOver, CarriedOverStory{id, sqid, title, continuedByID, continuedBySqid, continuedBySprintID})
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	return carriedOver, nil
+}
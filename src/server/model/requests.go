@@ -1,9 +1,28 @@
 package model
 
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+)
+
+// NullableString distinguishes a field that is absent from the request
+// from one that is explicitly set to null
+type NullableString struct {
+	Set   bool
+	Value *string
+}
+
+func (n *NullableString) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	return json.Unmarshal(data, &n.Value)
+}
+
 type CreateTaskReq struct {
 	Title       string  `json:"title"`
 	Description string  `json:"description"`
 	StoryID     *string `json:"story_id"`  // ptr allows for null values
+	BucketID    *string `json:"bucket_id"` // ptr allows for null values
 	BulkTask    *bool   `json:"bulk_task"` // ptr allows for null values
 }
 
@@ -13,30 +32,110 @@ type CreateCommentReq struct {
 }
 
 type PutCommentReq struct {
-	ID   int    `json:"id"`
-	Text string `json:"text"`
+	ID        int       `json:"id"`
+	Text      string    `json:"text"`
+	UpdatedAt time.Time `json:"updated_at"` // as last read by the client
+}
+
+type DestroyCommentReq struct {
+	ID int `json:"id"`
+}
+
+type CreateChecklistItemReq struct {
+	TaskID string `json:"task_id"`
+	Text   string `json:"text"`
+}
+
+type PutChecklistItemReq struct {
+	ID        int       `json:"id"`
+	Text      string    `json:"text"`
+	Done      bool      `json:"done"`
+	UpdatedAt time.Time `json:"updated_at"` // as last read by the client
+}
+
+// ReorderChecklistItemsReq lists every item of the task in its new order
+type ReorderChecklistItemsReq struct {
+	TaskID  string `json:"task_id"`
+	ItemIDs []int  `json:"item_ids"`
+}
+
+type DestroyChecklistItemReq struct {
+	ID int `json:"id"`
 }
 
 type PutStoryReq struct {
-	ID          string `json:"id"`
-	Status      string `json:"status"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	SprintID    string `json:"sprint_id"`
+	ID          string    `json:"id"`
+	Status      string    `json:"status"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	SprintID    string    `json:"sprint_id"`
+	UpdatedAt   time.Time `json:"updated_at"` // as last read by the client
 }
 
 type PutTaskReq struct {
-	ID          string  `json:"id"`
-	Status      string  `json:"status"`
-	Title       string  `json:"title"`
-	Description string  `json:"description"`
-	StoryID     *string `json:"story_id"`
+	ID          string    `json:"id"`
+	Status      string    `json:"status"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	StoryID     *string   `json:"story_id"`
+	BucketID    *string   `json:"bucket_id"`
+	UpdatedAt   time.Time `json:"updated_at"` // as last read by the client
+}
+
+// PatchTaskReq updates only the fields which are present
+type PatchTaskReq struct {
+	ID          string         `json:"id"`
+	Status      *string        `json:"status"`
+	Title       *string        `json:"title"`
+	Description *string        `json:"description"`
+	StoryID     NullableString `json:"story_id"`
+	BucketID    NullableString `json:"bucket_id"`
+	UpdatedAt   time.Time      `json:"updated_at"` // as last read by the client
+}
+
+type DestroyTaskReq struct {
+	ID string `json:"id"`
+}
+
+// PatchStoryReq updates only the fields which are present
+type PatchStoryReq struct {
+	ID          string    `json:"id"`
+	Status      *string   `json:"status"`
+	Title       *string   `json:"title"`
+	Description *string   `json:"description"`
+	SprintID    *string   `json:"sprint_id"`
+	UpdatedAt   time.Time `json:"updated_at"` // as last read by the client
+}
+
+type DestroyStoryReq struct {
+	ID string `json:"id"`
 }
 
 type CreateSprintReq struct {
-	Title     string `json:"title"`
-	StartDate string `json:"start_date"`
-	EndDate   string `json:"end_date"`
+	Title        string `json:"title"`
+	StartDate    string `json:"start_date"`
+	EndDate      string `json:"end_date"`
+	AllowOverlap bool   `json:"allow_overlap"` // skip the check against other sprints' dates
+}
+
+type PutSprintReq struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	StartDate    string    `json:"start_date"`
+	EndDate      string    `json:"end_date"`
+	AllowOverlap bool      `json:"allow_overlap"` // skip the check against other sprints' dates
+	UpdatedAt    time.Time `json:"updated_at"`    // as last read by the client
+}
+
+type RolloverSprintReq struct {
+	SprintID        string  `json:"sprint_id"`         // the sprint being finished
+	NextSprintID    *string `json:"next_sprint_id"`    // ptr allows for null values; null creates the next sprint
+	NextSprintTitle string  `json:"next_sprint_title"` // title of the created sprint
+	DryRun          bool    `json:"dry_run"`
+}
+
+type DestroySprintReq struct {
+	ID string `json:"id"`
 }
 
 type CreateStoryReq struct {
@@ -61,8 +160,21 @@ type DestroyTagAssignmentByIDReq struct {
 }
 
 type CreateTagReq struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	ParentID    *string `json:"parent_id"`
+}
+
+type PutTagReq struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	ParentID    *string   `json:"parent_id"`  // nil makes the tag a root tag
+	UpdatedAt   time.Time `json:"updated_at"` // as last read by the client
+}
+
+type DestroyTagReq struct {
+	ID string `json:"id"`
 }
 
 type CreateStoryRelationshipReq struct {
@@ -75,11 +187,33 @@ type DestroyStoryRelationshipByIDReq struct {
 	ID int `json:"id"`
 }
 
+type CreateTaskRelationshipReq struct {
+	TaskIDA  string `json:"task_id_a"`
+	TaskIDB  string `json:"task_id_b"`
+	Relation string `json:"relation"`
+}
+
+type DestroyTaskRelationshipByIDReq struct {
+	ID int `json:"id"`
+}
+
 type CreateBucketReq struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 }
 
+type PutBucketReq struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	UpdatedAt   time.Time `json:"updated_at"` // as last read by the client
+}
+
+type DestroyBucketReq struct {
+	ID string `json:"id"`
+}
+
 type CreateBucketTagAssignmentReq struct {
 	TagID    string `json:"tag_id"`
 	BucketID string `json:"bucket_id"`
@@ -89,9 +223,53 @@ type DestroyBucketTagAssignmentByIDReq struct {
 	ID int `json:"id"`
 }
 
+type CreateTaskTagAssignmentReq struct {
+	TagID  string `json:"tag_id"`
+	TaskID string `json:"task_id"`
+}
+
+type DestroyTaskTagAssignmentByIDReq struct {
+	ID int `json:"id"`
+}
+
 type CreateUploadWithArtifactsReq struct {
 	UploaderIP     *string
 	ClientFilename *string
 	UploadType     string
 	Artifacts      []UploadArtifact
 }
+
+type RestoreEntityReq struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+}
+
+type CreateSavedViewReq struct {
+	Name       string     `json:"name"`
+	EntityKind string     `json:"entity_kind"`
+	Filter     url.Values `json:"filter"`
+}
+
+type PutSavedViewReq struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	EntityKind string     `json:"entity_kind"`
+	Filter     url.Values `json:"filter"`
+	UpdatedAt  time.Time  `json:"updated_at"` // as last read by the client
+}
+
+type DestroySavedViewReq struct {
+	ID string `json:"id"`
+}
+
+// BulkUpdateReq is one change set for many tasks or stories.
+// Nil or absent fields are left unchanged; StoryID and BucketID apply to tasks only.
+type BulkUpdateReq struct {
+	EntityKind   string         `json:"entity_kind"` // task or story
+	IDs          []string       `json:"ids"`
+	Status       *string        `json:"status"`
+	StoryID      NullableString `json:"story_id"`
+	BucketID     NullableString `json:"bucket_id"`
+	AddTagIDs    []string       `json:"add_tag_ids"`
+	RemoveTagIDs []string       `json:"remove_tag_ids"`
+}
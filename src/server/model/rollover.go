@@ -0,0 +1,445 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/bschlaman/todo-app/database"
+	"github.com/jackc/pgx/v4"
+	"github.com/sqids/sqids-go"
+)
+
+// RolloverSprint carries every unfinished story of a sprint into the next sprint.
+// The next sprint is either given, or created to start the day after the sprint
+// ends and last sprint_duration_seconds (defaultDuration if that is not configured).
+// A created sprint is titled NextSprintTitle, or from sprint_title_template if that is blank.
+// Each story which is not DONE, ARCHIVE, DUPLICATE or DEPRIORITIZED, and has not
+// already been continued, is cloned into the next sprint along with its tag
+// assignments, and linked to its clone with a CONTINUED_BY relationship.
+// The story's BACKLOG and DOING tasks are cloned into the clone along with their
+// tag assignments and checklists.  The sprint itself is left as it was, so its
+// report is unchanged by the rollover.
+// Everything happens in one transaction, which a dry run rolls back.
+func RolloverSprint(log *logger.BLogger, s *sqids.Sqids, rolloverReq RolloverSprintReq, defaultDuration time.Duration) (*SprintRollover, error) {
+	if rolloverReq.SprintID == "" {
+		log.Error("rolloverSprint: SprintID blank")
+		return nil, InputError{}
+	}
+	rolloverReq.NextSprintID = nilIfBlank(rolloverReq.NextSprintID)
+	if rolloverReq.NextSprintID != nil && *rolloverReq.NextSprintID == rolloverReq.SprintID {
+		log.Error("rolloverSprint: cannot roll a sprint over into itself")
+		return nil, InputError{}
+	}
+
+	sprint, err := GetSprintByID(log, rolloverReq.SprintID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, InputError{}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var nextSprint *Sprint
+	if rolloverReq.NextSprintID != nil {
+		nextSprint, err = GetSprintByID(log, *rolloverReq.NextSprintID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, InputError{}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	rollover := &SprintRollover{DryRun: rolloverReq.DryRun, Sprint: *sprint, Stories: []RolledOverStory{}}
+
+	if nextSprint == nil {
+		// serialize with the sprint scheduler, which may be creating the same sprint
+		_, err = tx.Exec(context.Background(), `LOCK TABLE sprints IN SHARE ROW EXCLUSIVE MODE`)
+		if err != nil {
+			log.Errorf("failed to lock sprints: %v", err)
+			return nil, err
+		}
+
+		startDate, endDate, err := nextSprintDates(log, sprint, defaultDuration)
+		if err != nil {
+			return nil, err
+		}
+		title := rolloverReq.NextSprintTitle
+		if title == "" {
+			title, err = templatedSprintTitle(log, tx, startDate, endDate)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := checkMaxLens(log, lengthCheck{"sprint_title_max_len", title}); err != nil {
+			return nil, err
+		}
+		if err := checkSprintTitleFree(log, tx, title, ""); err != nil {
+			return nil, err
+		}
+		if err := checkSprintOverlap(log, tx, startDate, endDate, ""); err != nil {
+			return nil, err
+		}
+
+		var id string
+		var cAt, uAt, sd, ed time.Time
+		var edited bool
+
+		err = tx.QueryRow(context.Background(),
+			`INSERT INTO sprints (
+					updated_at,
+					title,
+					start_date,
+					end_date
+				) VALUES (
+					CURRENT_TIMESTAMP,
+					$1,
+					$2,
+					$3
+				) RETURNING
+					id,
+					created_at,
+					updated_at,
+					title,
+					start_date,
+					end_date,
+					edited`,
+			title,
+			startDate.Format("2006-01-02"),
+			endDate.Format("2006-01-02"),
+		).Scan(&id, &cAt, &uAt, &title, &sd, &ed, &edited)
+		if err != nil {
+			log.Errorf("failed to create next sprint: %v", err)
+			return nil, err
+		}
+		nextSprint = &Sprint{id, cAt, uAt, title, sd.Format("2006-01-02"), ed.Format("2006-01-02"), edited}
+		rollover.CreatedNextSprint = true
+	}
+	rollover.NextSprint = *nextSprint
+
+	rows, err := tx.Query(context.Background(),
+		`SELECT
+				s.id,
+				s.sqid,
+				s.created_at,
+				s.updated_at,
+				s.title,
+				s.description,
+				s.status,
+				s.sprint_id,
+				s.edited,
+				EXISTS (SELECT 1 FROM stories n
+					WHERE n.sprint_id = $2 AND n.title = s.title)
+				FROM stories s
+				WHERE s.sprint_id = $1
+				AND s.deleted_at IS NULL
+				AND s.status NOT IN ('DONE', 'ARCHIVE', 'DUPLICATE', 'DEPRIORITIZED')
+				AND NOT EXISTS (SELECT 1 FROM story_relationships r
+					WHERE r.story_id_a = s.id AND r.relation = 'CONTINUED_BY')
+				ORDER BY s.created_at`,
+		sprint.ID,
+		nextSprint.ID,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	var unfinishedStories []Story
+	for rows.Next() {
+		var id, sqid, title, desc, status, sprintID string
+		var cAt, uAt time.Time
+		var edited, titleTaken bool
+		rows.Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &sprintID, &edited, &titleTaken)
+		if titleTaken {
+			// story titles are unique within a sprint
+			rows.Close()
+			log.Errorf("rolloverSprint: sprint %s already has a story titled %s", nextSprint.ID, title)
+			return nil, InputError{}
+		}
+		unfinishedStories = append(unfinishedStories, Story{id, sqid, cAt, uAt, title, desc, status, sprintID, edited})
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	// numbers the clones, to keep their sqids apart
+	var clonedTaskCount int
+	for i, from := range unfinishedStories {
+		to, err := cloneStoryIntoSprint(log, tx, s, i, &from, nextSprint.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		tagIDs, err := queryStrings(log, tx,
+			`INSERT INTO tag_assignments (tag_id, story_id)
+				SELECT tag_id, $2 FROM tag_assignments WHERE story_id = $1
+				RETURNING tag_id`,
+			from.ID, to.ID,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		var relationshipID int
+		err = tx.QueryRow(context.Background(),
+			`INSERT INTO story_relationships (
+					story_id_a,
+					story_id_b,
+					relation
+				) VALUES ($1, $2, 'CONTINUED_BY')
+				RETURNING id`,
+			from.ID,
+			to.ID,
+		).Scan(&relationshipID)
+		if err != nil {
+			log.Errorf("failed to create story relationship: %v", err)
+			return nil, err
+		}
+
+		openTasks, err := queryOpenTasks(log, tx, from.ID)
+		if err != nil {
+			return nil, err
+		}
+		var tasks = []RolledOverTask{}
+		for _, fromTask := range openTasks {
+			toTask, err := cloneTaskIntoStory(log, tx, s, clonedTaskCount, &fromTask, to.ID)
+			if err != nil {
+				return nil, err
+			}
+			clonedTaskCount++
+			tasks = append(tasks, RolledOverTask{fromTask, *toTask})
+		}
+
+		rollover.Stories = append(rollover.Stories, RolledOverStory{from, *to, relationshipID, tasks, tagIDs})
+	}
+
+	if rolloverReq.DryRun {
+		return rollover, nil
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	return rollover, nil
+}
+
+// cloneStoryIntoSprint creates a copy of a story in another sprint.
+// n distinguishes the sqids of stories cloned within the same instant.
+func cloneStoryIntoSprint(log *logger.BLogger, tx pgx.Tx, s *sqids.Sqids, n int, from *Story, sprintID string) (*Story, error) {
+	var id, sqid, title, desc, status, toSprintID string
+	var cAt, uAt time.Time
+	var edited bool
+
+	// generate the sqid
+	sq, _ := s.Encode([]uint64{uint64(time.Now().UnixNano()) + uint64(n)})
+
+	err := tx.QueryRow(context.Background(),
+		`INSERT INTO stories (
+				updated_at,
+				title,
+				description,
+				sprint_id,
+				sqid
+			) VALUES (
+				CURRENT_TIMESTAMP,
+				$1,
+				$2,
+				$3,
+				$4
+			) RETURNING
+				id,
+				sqid,
+				created_at,
+				updated_at,
+				title,
+				description,
+				status,
+				sprint_id,
+				edited`,
+		from.Title,
+		from.Description,
+		sprintID,
+		sq,
+	).Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &toSprintID, &edited)
+	if err != nil {
+		log.Errorf("failed to clone story: %v", err)
+		return nil, err
+	}
+
+	to := &Story{id, sqid, cAt, uAt, title, desc, status, toSprintID, edited}
+	if err := recordHistory(log, tx, HistoryKindStory, id, HistoryActionCreate, diffFields(nil, storyHistoryFields(to))); err != nil {
+		return nil, err
+	}
+
+	return to, nil
+}
+
+// queryOpenTasks returns the BACKLOG and DOING tasks of a story
+func queryOpenTasks(log *logger.BLogger, tx pgx.Tx, storyID string) ([]Task, error) {
+	rows, err := tx.Query(context.Background(),
+		`SELECT
+				id,
+				sqid,
+				created_at,
+				updated_at,
+				title,
+				description,
+				status,
+				story_id,
+				bucket_id,
+				edited,
+				bulk_task
+				FROM tasks
+				WHERE story_id = $1
+				AND deleted_at IS NULL
+				AND status IN ('BACKLOG', 'DOING')
+				ORDER BY created_at`,
+		storyID,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks = []Task{}
+	for rows.Next() {
+		var id, sqid, title, desc, status string
+		var sID, bucketID *string
+		var cAt, uAt time.Time
+		var edited, bulkTask bool
+		rows.Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &sID, &bucketID, &edited, &bulkTask)
+		tasks = append(tasks, Task{id, sqid, cAt, uAt, title, desc, status, sID, bucketID, edited, bulkTask, nil, nil})
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	return tasks, nil
+}
+
+// cloneTaskIntoStory creates a copy of a task, with its tag assignments and
+// checklist, in another story.  n distinguishes the sqids of tasks cloned
+// within the same instant.
+func cloneTaskIntoStory(log *logger.BLogger, tx pgx.Tx, s *sqids.Sqids, n int, from *Task, storyID string) (*Task, error) {
+	var id, sqid, title, desc, status string
+	var toStoryID, bucketID *string
+	var cAt, uAt time.Time
+	var edited, bulkTask bool
+
+	// generate the sqid, at the millisecond scale CreateTask uses
+	sq, _ := s.Encode([]uint64{uint64(time.Now().UnixNano()/1e6) + uint64(n)})
+
+	err := tx.QueryRow(context.Background(),
+		`INSERT INTO tasks (
+				updated_at,
+				title,
+				description,
+				status,
+				story_id,
+				bulk_task,
+				sqid
+			) VALUES (
+				CURRENT_TIMESTAMP,
+				$1,
+				$2,
+				$3,
+				$4,
+				$5,
+				$6
+			) RETURNING
+				id,
+				sqid,
+				created_at,
+				updated_at,
+				title,
+				description,
+				status,
+				story_id,
+				bucket_id,
+				edited,
+				bulk_task`,
+		from.Title,
+		from.Description,
+		from.Status,
+		storyID,
+		from.BulkTask,
+		sq,
+	).Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &toStoryID, &bucketID, &edited, &bulkTask)
+	if err != nil {
+		log.Errorf("failed to clone task: %v", err)
+		return nil, err
+	}
+
+	to := &Task{id, sqid, cAt, uAt, title, desc, status, toStoryID, bucketID, edited, bulkTask, nil, nil}
+	if err := recordHistory(log, tx, HistoryKindTask, id, HistoryActionCreate, diffFields(nil, taskHistoryFields(to))); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(context.Background(),
+		`INSERT INTO task_tag_assignments (tag_id, task_id)
+			SELECT tag_id, $2 FROM task_tag_assignments WHERE task_id = $1`,
+		from.ID, id,
+	)
+	if err != nil {
+		log.Errorf("failed to clone task tag assignments: %v", err)
+		return nil, err
+	}
+	_, err = tx.Exec(context.Background(),
+		`INSERT INTO checklist_items (updated_at, task_id, text, done, position)
+			SELECT CURRENT_TIMESTAMP, $2, text, done, position
+			FROM checklist_items WHERE task_id = $1`,
+		from.ID, id,
+	)
+	if err != nil {
+		log.Errorf("failed to clone checklist: %v", err)
+		return nil, err
+	}
+
+	return to, nil
+}
+
+// queryStrings runs a query within tx which returns a single text column
+func queryStrings(log *logger.BLogger, tx pgx.Tx, sql string, args ...interface{}) ([]string, error) {
+	rows, err := tx.Query(context.Background(), sql, args...)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values = []string{}
+	for rows.Next() {
+		var value string
+		rows.Scan(&value)
+		values = append(values, value)
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	return values, nil
+}
@@ -0,0 +1,384 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/bschlaman/todo-app/database"
+	"github.com/jackc/pgx/v4"
+)
+
+// Kinds of entities a saved view lists
+const (
+	SavedViewKindTask  = "task"
+	SavedViewKindStory = "story"
+)
+
+// currentSprintParam may be stored as a view's sprint_id,
+// to follow whichever sprint is current when the view is run
+const currentSprintParam = "current"
+
+// savedViewParams are the list parameters a view of each kind may store.
+// cursor and limit are given when the view is run, not stored.
+var savedViewParams = map[string]map[string]bool{
+	SavedViewKindTask: {
+		"sort": true, "status": true, "story_id": true, "sprint_id": true,
+		"bucket_id": true, "tag_id": true, "bulk_task": true,
+		"created_after": true, "created_before": true,
+		"updated_after": true, "updated_before": true,
+	},
+	SavedViewKindStory: {
+		"sort": true, "status": true, "sprint_id": true, "tag_id": true,
+		"created_after": true, "created_before": true,
+		"updated_after": true, "updated_before": true,
+	},
+}
+
+// checkSavedViewFilter returns an InputError unless filter holds only
+// parameters of the view's kind, each valid for get_tasks or get_stories
+func checkSavedViewFilter(log *logger.BLogger, entityKind string, filter url.Values) error {
+	params, ok := savedViewParams[entityKind]
+	if !ok {
+		log.Errorf("invalid saved view entity kind %q", entityKind)
+		return InputError{}
+	}
+	for key := range filter {
+		if !params[key] {
+			log.Errorf("a %s view cannot store %q", entityKind, key)
+			return InputError{}
+		}
+	}
+
+	// any sprint id will do to validate the rest of the filter
+	values := url.Values{}
+	for key, vals := range filter {
+		values[key] = vals
+	}
+	if values.Get("sprint_id") == currentSprintParam {
+		values.Set("sprint_id", "00000000-0000-0000-0000-000000000000")
+	}
+
+	var err error
+	switch entityKind {
+	case SavedViewKindTask:
+		_, err = ParseTaskFilter(log, values)
+	case SavedViewKindStory:
+		_, err = ParseStoryFilter(log, values)
+	}
+	return err
+}
+
+func GetSavedViews(log *logger.BLogger) ([]SavedView, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT
+				id,
+				created_at,
+				updated_at,
+				name,
+				entity_kind,
+				filter,
+				edited
+				FROM saved_views
+				ORDER BY name`,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var views = []SavedView{}
+	for rows.Next() {
+		var id, name, entityKind string
+		var cAt, uAt time.Time
+		var filter url.Values
+		var edited bool
+		rows.Scan(&id, &cAt, &uAt, &name, &entityKind, &filter, &edited)
+		views = append(views, SavedView{id, cAt, uAt, name, entityKind, filter, edited})
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	return views, nil
+}
+
+func GetSavedViewByID(log *logger.BLogger, viewID string) (*SavedView, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	var id, name, entityKind string
+	var cAt, uAt time.Time
+	var filter url.Values
+	var edited bool
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT
+				id,
+				created_at,
+				updated_at,
+				name,
+				entity_kind,
+				filter,
+				edited
+				FROM saved_views
+				WHERE id = $1`,
+		viewID,
+	).Scan(&id, &cAt, &uAt, &name, &entityKind, &filter, &edited)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+
+	return &SavedView{id, cAt, uAt, name, entityKind, filter, edited}, nil
+}
+
+func CreateSavedView(log *logger.BLogger, createReq CreateSavedViewReq) (*SavedView, error) {
+	if createReq.Name == "" {
+		log.Error("createSavedView: Name blank")
+		return nil, InputError{}
+	}
+	if err := checkMaxLens(log,
+		lengthCheck{"saved_view_name_max_len", createReq.Name},
+	); err != nil {
+		return nil, err
+	}
+	if createReq.Filter == nil {
+		createReq.Filter = url.Values{}
+	}
+	if err := checkSavedViewFilter(log, createReq.EntityKind, createReq.Filter); err != nil {
+		return nil, err
+	}
+	js, err := json.Marshal(createReq.Filter)
+	if err != nil {
+		log.Errorf("json.Marshal failed: %v", err)
+		return nil, err
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	var id, name, entityKind string
+	var cAt, uAt time.Time
+	var filter url.Values
+	var edited bool
+
+	err = conn.QueryRow(context.Background(),
+		`INSERT INTO saved_views (
+				updated_at,
+				name,
+				entity_kind,
+				filter
+			) VALUES (
+				CURRENT_TIMESTAMP,
+				$1,
+				$2,
+				$3
+			) RETURNING
+				id,
+				created_at,
+				updated_at,
+				name,
+				entity_kind,
+				filter,
+				edited`,
+		createReq.Name,
+		createReq.EntityKind,
+		string(js),
+	).Scan(&id, &cAt, &uAt, &name, &entityKind, &filter, &edited)
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return nil, err
+	}
+
+	return &SavedView{id, cAt, uAt, name, entityKind, filter, edited}, nil
+}
+
+func PutSavedView(log *logger.BLogger, putReq PutSavedViewReq) (*SavedView, error) {
+	if putReq.Name == "" || putReq.UpdatedAt.IsZero() {
+		log.Error("putSavedView: Name or UpdatedAt blank")
+		return nil, InputError{}
+	}
+	if err := checkMaxLens(log,
+		lengthCheck{"saved_view_name_max_len", putReq.Name},
+	); err != nil {
+		return nil, err
+	}
+	if putReq.Filter == nil {
+		putReq.Filter = url.Values{}
+	}
+	if err := checkSavedViewFilter(log, putReq.EntityKind, putReq.Filter); err != nil {
+		return nil, err
+	}
+	js, err := json.Marshal(putReq.Filter)
+	if err != nil {
+		log.Errorf("json.Marshal failed: %v", err)
+		return nil, err
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	var id, name, entityKind string
+	var cAt, uAt time.Time
+	var filter url.Values
+	var edited bool
+
+	err = conn.QueryRow(context.Background(),
+		`UPDATE saved_views SET
+			updated_at = CURRENT_TIMESTAMP,
+			name = $1,
+			entity_kind = $2,
+			filter = $3,
+			edited = true
+			WHERE id = $4 AND updated_at = $5
+			RETURNING
+				id,
+				created_at,
+				updated_at,
+				name,
+				entity_kind,
+				filter,
+				edited`,
+		putReq.Name,
+		putReq.EntityKind,
+		string(js),
+		putReq.ID,
+		putReq.UpdatedAt,
+	).Scan(&id, &cAt, &uAt, &name, &entityKind, &filter, &edited)
+	if errors.Is(err, pgx.ErrNoRows) {
+		current, err := GetSavedViewByID(log, putReq.ID)
+		if err != nil {
+			return nil, InputError{}
+		}
+		return nil, ConflictError{current}
+	}
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return nil, err
+	}
+
+	return &SavedView{id, cAt, uAt, name, entityKind, filter, edited}, nil
+}
+
+func DestroySavedView(log *logger.BLogger, destroyReq DestroySavedViewReq) error {
+	if destroyReq.ID == "" {
+		log.Error("destroySavedView: ID blank")
+		return InputError{}
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(context.Background(),
+		`DELETE FROM saved_views WHERE id = $1`,
+		destroyReq.ID,
+	)
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		log.Errorf("destroySavedView: no saved view with id %s", destroyReq.ID)
+		return InputError{}
+	}
+
+	return nil
+}
+
+// GetViewResults runs a saved view's filter against tasks or stories.
+// page holds the cursor and limit of the page to return; anything else in it
+// is ignored.  A sprint_id of "current" resolves to the sprint including now,
+// and matches nothing if there is no such sprint.
+func GetViewResults(log *logger.BLogger, viewID string, page url.Values) (*ViewResults, error) {
+	view, err := GetSavedViewByID(log, viewID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, InputError{}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	for key, vals := range view.Filter {
+		values[key] = vals
+	}
+	for _, key := range []string{"cursor", "limit"} {
+		if v := page.Get(key); v != "" {
+			values.Set(key, v)
+		}
+	}
+
+	noSprint := false
+	if values.Get("sprint_id") == currentSprintParam {
+		sprint, err := GetCurrentSprint(log, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if sprint == nil {
+			noSprint = true
+		} else {
+			values.Set("sprint_id", sprint.ID)
+		}
+	}
+
+	results := &ViewResults{View: *view}
+	switch view.EntityKind {
+	case SavedViewKindTask:
+		filter, err := ParseTaskFilter(log, values)
+		if err != nil {
+			return nil, err
+		}
+		if noSprint {
+			results.Tasks = &Page[Task]{Items: []Task{}}
+			return results, nil
+		}
+		results.Tasks, err = GetTasks(log, filter)
+		if err != nil {
+			return nil, err
+		}
+	case SavedViewKindStory:
+		filter, err := ParseStoryFilter(log, values)
+		if err != nil {
+			return nil, err
+		}
+		if noSprint {
+			results.Stories = &Page[Story]{Items: []Story{}}
+			return results, nil
+		}
+		results.Stories, err = GetStories(log, filter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,210 @@
+package model
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/bschlaman/todo-app/database"
+)
+
+// Kinds of search result
+const (
+	SearchKindTask    = "task"
+	SearchKindStory   = "story"
+	SearchKindBucket  = "bucket"
+	SearchKindComment = "comment"
+)
+
+const (
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 200
+)
+
+// bucketStatuses mirrors the bucket_status enum
+var bucketStatuses = map[string]bool{
+	"ACTIVE":   true,
+	"INACTIVE": true,
+	"ARCHIVED": true,
+}
+
+// The documents searched for each kind.  These must match the expressions of
+// the search indexes in model/migrations/add_search_indexes.sql, or the
+// indexes will not be used.
+const (
+	taskSearchDoc    = `setweight(to_tsvector('english', t.title), 'A') || setweight(to_tsvector('english', coalesce(t.description, '')), 'B')`
+	storySearchDoc   = `setweight(to_tsvector('english', s.title), 'A') || setweight(to_tsvector('english', coalesce(s.description, '')), 'B')`
+	bucketSearchDoc  = `setweight(to_tsvector('english', b.title), 'A') || setweight(to_tsvector('english', coalesce(b.description, '')), 'B')`
+	commentSearchDoc = `to_tsvector('english', c.text)`
+)
+
+// searchHeadlineOptions marks matches in snippets with <mark></mark>.
+// The text is HTML escaped by htmlEscapeSQL before the marks are added,
+// so a snippet is safe to render as HTML.
+const searchHeadlineOptions = `StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2`
+
+// htmlEscapeSQL wraps a text expression so that it is HTML escaped.
+// The default text search parser reads each entity as a single token,
+// so ts_headline never splits one.
+func htmlEscapeSQL(expr string) string {
+	return `replace(replace(replace(replace(replace(` + expr +
+		`, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`
+}
+
+// SearchFilter narrows a search.  Statuses and SprintID apply to a comment
+// through its task, and a bucket never matches a SprintID.
+type SearchFilter struct {
+	Query    string
+	Kinds    []string
+	Statuses []string
+	SprintID *string
+	Limit    int
+}
+
+func ParseSearchFilter(log *logger.BLogger, q url.Values) (SearchFilter, error) {
+	f := SearchFilter{Query: strings.TrimSpace(q.Get("q")), Limit: DefaultSearchLimit}
+	if f.Query == "" {
+		log.Error("search: q blank")
+		return f, InputError{}
+	}
+
+	for _, param := range q["kind"] {
+		for _, kind := range strings.Split(param, ",") {
+			switch kind {
+			case SearchKindTask, SearchKindStory, SearchKindBucket, SearchKindComment:
+				f.Kinds = append(f.Kinds, kind)
+			default:
+				log.Errorf("invalid search kind %q", kind)
+				return f, InputError{}
+			}
+		}
+	}
+
+	allStatuses := make(map[string]bool)
+	for _, statuses := range []map[string]bool{taskStatuses, storyStatuses, bucketStatuses} {
+		for status := range statuses {
+			allStatuses[status] = true
+		}
+	}
+	var err error
+	if f.Statuses, err = parseStatuses(log, q, allStatuses); err != nil {
+		return f, err
+	}
+	if f.SprintID, err = parseUUIDParam(log, q, "sprint_id"); err != nil {
+		return f, err
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > MaxSearchLimit {
+			log.Errorf("limit must be between 1 and %d, got %q", MaxSearchLimit, limit)
+			return f, InputError{}
+		}
+		f.Limit = n
+	}
+
+	return f, nil
+}
+
+// Search runs a full-text search over the titles and descriptions of tasks,
+// stories and buckets and the text of comments, leaving out anything in the
+// trash.  The query is parsed like a web search: quoted phrases, OR and -word.
+// Results are ordered by rank, best first.
+func Search(log *logger.BLogger, filter SearchFilter) ([]SearchResult, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	kinds := filter.Kinds
+	if len(kinds) == 0 {
+		kinds = []string{SearchKindTask, SearchKindStory, SearchKindBucket, SearchKindComment}
+	}
+
+	lq := listQuery{}
+	query := lq.arg(filter.Query)
+	lq.where("kind = ANY(" + lq.arg(kinds) + ")")
+	if len(filter.Statuses) > 0 {
+		lq.where("status = ANY(" + lq.arg(filter.Statuses) + ")")
+	}
+	if filter.SprintID != nil {
+		lq.where("sprint_id = " + lq.arg(*filter.SprintID))
+	}
+	where := "WHERE " + strings.Join(lq.conds, " AND ")
+	limit := lq.arg(filter.Limit)
+
+	rows, err := conn.Query(context.Background(),
+		`WITH q AS (SELECT websearch_to_tsquery('english', `+query+`) AS query),
+			results AS (
+				SELECT 'task' AS kind, t.id::text AS id, t.sqid, t.title,
+						t.status::text AS status, s.sprint_id,
+						ts_rank(`+taskSearchDoc+`, q.query) AS rank,
+						ts_headline('english', `+htmlEscapeSQL("t.title || ' ' || coalesce(t.description, '')")+`, q.query, '`+searchHeadlineOptions+`') AS snippet
+					FROM tasks t
+					CROSS JOIN q
+					LEFT JOIN stories s ON s.id = t.story_id
+					WHERE t.deleted_at IS NULL
+					AND `+taskSearchDoc+` @@ q.query
+				UNION ALL
+				SELECT 'story', s.id::text, s.sqid, s.title,
+						s.status::text, s.sprint_id,
+						ts_rank(`+storySearchDoc+`, q.query),
+						ts_headline('english', `+htmlEscapeSQL("s.title || ' ' || coalesce(s.description, '')")+`, q.query, '`+searchHeadlineOptions+`')
+					FROM stories s
+					CROSS JOIN q
+					WHERE s.deleted_at IS NULL
+					AND `+storySearchDoc+` @@ q.query
+				UNION ALL
+				SELECT 'bucket', b.id::text, b.sqid, b.title,
+						b.status::text, NULL,
+						ts_rank(`+bucketSearchDoc+`, q.query),
+						ts_headline('english', `+htmlEscapeSQL("b.title || ' ' || coalesce(b.description, '')")+`, q.query, '`+searchHeadlineOptions+`')
+					FROM buckets b
+					CROSS JOIN q
+					WHERE b.deleted_at IS NULL
+					AND `+bucketSearchDoc+` @@ q.query
+				UNION ALL
+				SELECT 'comment', c.id::text, t.sqid, t.title,
+						t.status::text, s.sprint_id,
+						ts_rank(`+commentSearchDoc+`, q.query),
+						ts_headline('english', `+htmlEscapeSQL("c.text")+`, q.query, '`+searchHeadlineOptions+`')
+					FROM comments c
+					CROSS JOIN q
+					JOIN tasks t ON t.id = c.task_id
+					LEFT JOIN stories s ON s.id = t.story_id
+					WHERE c.deleted_at IS NULL
+					AND t.deleted_at IS NULL
+					AND `+commentSearchDoc+` @@ q.query
+			)
+			SELECT kind, id, sqid, title, status, sprint_id, rank, snippet
+				FROM results
+				`+where+`
+				ORDER BY rank DESC, kind, id
+				LIMIT `+limit,
+		lq.args...,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results = []SearchResult{}
+	for rows.Next() {
+		var kind, id, sqid, title, status, snippet string
+		var sprintID *string
+		var rank float32
+		rows.Scan(&kind, &id, &sqid, &title, &status, &sprintID, &rank, &snippet)
+		results = append(results, SearchResult{kind, id, sqid, title, status, sprintID, rank, snippet})
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	return results, nil
+}
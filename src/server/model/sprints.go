@@ -0,0 +1,346 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/bschlaman/todo-app/database"
+	"github.com/jackc/pgx/v4"
+)
+
+// defaultSprintTitleTemplate is used if the config table has no sprint_title_template
+const defaultSprintTitleTemplate = "Sprint {number}"
+
+// GetCurrentSprint returns the sprint whose dates include today,
+// or nil if there is none
+func GetCurrentSprint(log *logger.BLogger, today time.Time) (*Sprint, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	var id, title string
+	var cAt, uAt, sd, ed time.Time
+	var edited bool
+
+	err = conn.QueryRow(context.Background(),
+		`SELECT
+				id,
+				created_at,
+				updated_at,
+				title,
+				start_date,
+				end_date,
+				edited
+				FROM sprints
+				WHERE start_date <= $1::date AND end_date >= $1::date
+				ORDER BY start_date DESC
+				LIMIT 1`,
+		today.Format("2006-01-02"),
+	).Scan(&id, &cAt, &uAt, &title, &sd, &ed, &edited)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+
+	return &Sprint{id, cAt, uAt, title, sd.Format("2006-01-02"), ed.Format("2006-01-02"), edited}, nil
+}
+
+// CreateNextSprintIfDue creates the sprint following the latest sprint once
+// the latest sprint ends within leadTime of now.  The new sprint starts the day
+// after the latest one ends, lasts sprint_duration_seconds (defaultDuration if
+// that is not configured) and is titled from sprint_title_template.
+// If the latest sprint ended before today the calendar has lapsed; rather than
+// filling the past, the new sprint starts today.
+// Returns nil if no sprint was due, or if there are no sprints to follow.
+func CreateNextSprintIfDue(log *logger.BLogger, now time.Time, leadTime, defaultDuration time.Duration) (*Sprint, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	// serialize with other sprint creation so two servers cannot both create the next sprint
+	_, err = tx.Exec(context.Background(), `LOCK TABLE sprints IN SHARE ROW EXCLUSIVE MODE`)
+	if err != nil {
+		log.Errorf("failed to lock sprints: %v", err)
+		return nil, err
+	}
+
+	var latest Sprint
+	var sd, ed time.Time
+	err = tx.QueryRow(context.Background(),
+		`SELECT id, title, start_date, end_date
+				FROM sprints
+				ORDER BY end_date DESC
+				LIMIT 1`,
+	).Scan(&latest.ID, &latest.Title, &sd, &ed)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	latest.StartDate, latest.EndDate = sd.Format("2006-01-02"), ed.Format("2006-01-02")
+
+	// the latest sprint runs through the whole of its end date
+	if now.Add(leadTime).Before(ed.AddDate(0, 0, 1)) {
+		return nil, nil
+	}
+
+	startDate, endDate, err := nextSprintDates(log, &latest, defaultDuration)
+	if err != nil {
+		return nil, err
+	}
+	today, _ := time.Parse("2006-01-02", now.Format("2006-01-02"))
+	if startDate.Before(today) {
+		log.Infof("no sprint since %s; starting the next sprint today", latest.EndDate)
+		endDate = today.Add(endDate.Sub(startDate))
+		startDate = today
+	}
+	title, err := templatedSprintTitle(log, tx, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkMaxLens(log, lengthCheck{"sprint_title_max_len", title}); err != nil {
+		return nil, err
+	}
+	if err := checkSprintTitleFree(log, tx, title, ""); err != nil {
+		return nil, err
+	}
+	if err := checkSprintOverlap(log, tx, startDate, endDate, ""); err != nil {
+		return nil, err
+	}
+
+	var id string
+	var cAt, uAt time.Time
+	var edited bool
+
+	err = tx.QueryRow(context.Background(),
+		`INSERT INTO sprints (
+				updated_at,
+				title,
+				start_date,
+				end_date
+			) VALUES (
+				CURRENT_TIMESTAMP,
+				$1,
+				$2,
+				$3
+			) RETURNING
+				id,
+				created_at,
+				updated_at,
+				title,
+				start_date,
+				end_date,
+				edited`,
+		title,
+		startDate.Format("2006-01-02"),
+		endDate.Format("2006-01-02"),
+	).Scan(&id, &cAt, &uAt, &title, &sd, &ed, &edited)
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return nil, err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	return &Sprint{id, cAt, uAt, title, sd.Format("2006-01-02"), ed.Format("2006-01-02"), edited}, nil
+}
+
+// nextSprintDates returns the dates of the sprint following sprint, which starts
+// the day after it ends and lasts sprint_duration_seconds, or defaultDuration
+// if that is not configured
+func nextSprintDates(log *logger.BLogger, sprint *Sprint, defaultDuration time.Duration) (time.Time, time.Time, error) {
+	serverConfig, err := GetConfig(log)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	duration := defaultDuration
+	if seconds, ok := serverConfig["sprint_duration_seconds"].(int64); ok && seconds > 0 {
+		duration = time.Duration(seconds) * time.Second
+	}
+	days := int(duration / (24 * time.Hour))
+	if days < 2 {
+		log.Errorf("sprint duration too short: %v", duration)
+		return time.Time{}, time.Time{}, errors.New("sprint duration too short")
+	}
+
+	endDate, err := time.Parse("2006-01-02", sprint.EndDate)
+	if err != nil {
+		log.Errorf("invalid sprint end date: %v", sprint.EndDate)
+		return time.Time{}, time.Time{}, err
+	}
+	// like the sprintboard, a sprint includes both its start and end date
+	startDate := endDate.AddDate(0, 0, 1)
+	return startDate, startDate.AddDate(0, 0, days-1), nil
+}
+
+// templatedSprintTitle renders sprint_title_template for a new sprint.
+// {number} is replaced by the number of the new sprint,
+// {start_date} and {end_date} by its dates.  Sprints can be destroyed, so the
+// number follows the highest number among titles rendered from the template
+// rather than the count of sprints, and is never reused.
+func templatedSprintTitle(log *logger.BLogger, q queryer, startDate, endDate time.Time) (string, error) {
+	serverConfig, err := GetConfig(log)
+	if err != nil {
+		return "", err
+	}
+	template, ok := serverConfig["sprint_title_template"].(string)
+	if !ok || template == "" {
+		template = defaultSprintTitleTemplate
+	}
+
+	rows, err := q.Query(context.Background(), `SELECT title FROM sprints`)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return "", err
+	}
+	defer rows.Close()
+
+	// sprints numbered before the template was introduced are counted too
+	pattern := sprintTitlePattern(template)
+	var sprintCount, highest int
+	for rows.Next() {
+		var title string
+		rows.Scan(&title)
+		sprintCount++
+		if m := pattern.FindStringSubmatch(title); len(m) > 1 {
+			if n, err := strconv.Atoi(m[1]); err == nil && n > highest {
+				highest = n
+			}
+		}
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return "", rows.Err()
+	}
+	if sprintCount > highest {
+		highest = sprintCount
+	}
+
+	return strings.NewReplacer(
+		"{number}", strconv.Itoa(highest+1),
+		"{start_date}", startDate.Format("2006-01-02"),
+		"{end_date}", endDate.Format("2006-01-02"),
+	).Replace(template), nil
+}
+
+// sprintTitlePattern matches the titles rendered from template,
+// capturing the first {number}
+func sprintTitlePattern(template string) *regexp.Regexp {
+	return regexp.MustCompile("^" + strings.NewReplacer(
+		`\{number\}`, `(\d+)`,
+		`\{start_date\}`, `\d{4}-\d{2}-\d{2}`,
+		`\{end_date\}`, `\d{4}-\d{2}-\d{2}`,
+	).Replace(regexp.QuoteMeta(template)) + "$")
+}
+
+// kinds of SprintCalendarIssue
+const (
+	SprintCalendarIssueOverlap = "overlap"
+	SprintCalendarIssueGap     = "gap"
+)
+
+// GetSprintCalendarIssues lists every pair of overlapping sprints and every gap
+// between the first sprint's start and the last sprint's end, in date order
+func GetSprintCalendarIssues(log *logger.BLogger) ([]SprintCalendarIssue, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT id, start_date, end_date
+				FROM sprints
+				ORDER BY start_date, end_date, id`,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	type sprintDates struct {
+		id         string
+		start, end time.Time
+	}
+	var sprints []sprintDates
+	for rows.Next() {
+		var sd sprintDates
+		rows.Scan(&sd.id, &sd.start, &sd.end)
+		sprints = append(sprints, sd)
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	var issues = []SprintCalendarIssue{}
+	// latest is the sprint seen so far which ends last
+	var latest sprintDates
+	for i, sprint := range sprints {
+		for _, other := range sprints[i+1:] {
+			if other.start.After(sprint.end) {
+				break
+			}
+			overlapEnd := sprint.end
+			if other.end.Before(overlapEnd) {
+				overlapEnd = other.end
+			}
+			issues = append(issues, SprintCalendarIssue{
+				SprintCalendarIssueOverlap,
+				sprint.id,
+				other.id,
+				other.start.Format("2006-01-02"),
+				overlapEnd.Format("2006-01-02"),
+			})
+		}
+
+		if i > 0 && sprint.start.After(latest.end.AddDate(0, 0, 1)) {
+			issues = append(issues, SprintCalendarIssue{
+				SprintCalendarIssueGap,
+				latest.id,
+				sprint.id,
+				latest.end.AddDate(0, 0, 1).Format("2006-01-02"),
+				sprint.start.AddDate(0, 0, -1).Format("2006-01-02"),
+			})
+		}
+		if i == 0 || sprint.end.After(latest.end) {
+			latest = sprint
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].StartDate < issues[j].StartDate
+	})
+
+	return issues, nil
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"context"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/bschlaman/todo-app/database"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// maxStoryGraphDepth bounds the walk of the graph.  Cycles are rejected when
+// relationships are created, so this is only a backstop.
+const maxStoryGraphDepth = 100
+
+// GetStoryGraph returns the stories which transitively block a story,
+// and the stories it transitively blocks.  Stories in the trash are left out.
+func GetStoryGraph(log *logger.BLogger, storyID string) (*StoryGraph, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	// blockers walk BLOCKS relationships from story B back to story A
+	blockers, err := getBlocksClosure(log, conn, storyID, "story_id_a", "story_id_b")
+	if err != nil {
+		return nil, err
+	}
+	dependents, err := getBlocksClosure(log, conn, storyID, "story_id_b", "story_id_a")
+	if err != nil {
+		return nil, err
+	}
+
+	return &StoryGraph{storyID, blockers, dependents}, nil
+}
+
+// getBlocksClosure follows BLOCKS relationships from storyID, from the
+// toColumn end of each relationship to its nextColumn end
+func getBlocksClosure(log *logger.BLogger, conn *pgxpool.Conn, storyID, nextColumn, toColumn string) ([]StoryGraphNode, error) {
+	rows, err := conn.Query(context.Background(),
+		`WITH RECURSIVE closure(story_id, depth) AS (
+				SELECT `+nextColumn+`, 1
+					FROM story_relationships
+					WHERE relation = 'BLOCKS' AND `+toColumn+` = $1
+				UNION
+				SELECT r.`+nextColumn+`, c.depth + 1
+					FROM story_relationships r
+					JOIN closure c ON r.`+toColumn+` = c.story_id
+					WHERE r.relation = 'BLOCKS'
+					AND c.depth < $2
+			)
+			SELECT s.id, s.sqid, s.title, s.status, MIN(c.depth)
+				FROM closure c
+				JOIN stories s ON s.id = c.story_id
+				WHERE s.deleted_at IS NULL
+				GROUP BY s.id, s.sqid, s.title, s.status
+				ORDER BY MIN(c.depth), s.title`,
+		storyID,
+		maxStoryGraphDepth,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nodes = []StoryGraphNode{}
+	for rows.Next() {
+		var id, sqid, title, status string
+		var depth int
+		rows.Scan(&id, &sqid, &title, &status, &depth)
+		nodes = append(nodes, StoryGraphNode{id, sqid, title, status, depth})
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	return nodes, nil
+}
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/bschlaman/todo-app/database"
+	"github.com/jackc/pgx/v4"
+)
+
+// tagSubtreeSQL selects the id of the tag given by placeholder along with the
+// ids of all of its descendants.  It is used as the right side of IN (...).
+func tagSubtreeSQL(placeholder string) string {
+	return `WITH RECURSIVE tag_subtree(id) AS (
+			SELECT id FROM tags WHERE id = ` + placeholder + `
+			UNION
+			SELECT t.id FROM tags t JOIN tag_subtree s ON t.parent_id = s.id
+		) SELECT id FROM tag_subtree`
+}
+
+// GetTagTree returns the root tags, each with its descendants nested
+// under it.  Siblings are sorted by title.
+func GetTagTree(log *logger.BLogger) ([]TagNode, error) {
+	tags, err := getAllTags(log)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Title < tags[j].Title })
+
+	childrenOf := make(map[string][]Tag)
+	exists := make(map[string]bool)
+	for _, tag := range tags {
+		exists[tag.ID] = true
+	}
+	var roots []Tag
+	for _, tag := range tags {
+		if tag.ParentID == nil || !exists[*tag.ParentID] {
+			roots = append(roots, tag)
+			continue
+		}
+		childrenOf[*tag.ParentID] = append(childrenOf[*tag.ParentID], tag)
+	}
+
+	var build func(tags []Tag) []TagNode
+	build = func(tags []Tag) []TagNode {
+		var nodes = []TagNode{}
+		for _, tag := range tags {
+			nodes = append(nodes, TagNode{tag, build(childrenOf[tag.ID])})
+		}
+		return nodes
+	}
+	return build(roots), nil
+}
+
+// refreshTagIsParent sets is_parent on exactly the tags which have children
+func refreshTagIsParent(log *logger.BLogger, tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(),
+		`UPDATE tags p
+			SET is_parent = EXISTS (SELECT 1 FROM tags c WHERE c.parent_id = p.id)
+			WHERE is_parent <> EXISTS (SELECT 1 FROM tags c WHERE c.parent_id = p.id)`,
+	)
+	if err != nil {
+		log.Errorf("failed to refresh tags.is_parent: %v", err)
+		return err
+	}
+	return nil
+}
+
+// lockTagsForReparent serializes changes to tags.parent_id so that two
+// concurrent moves cannot together form a cycle or exceed tag_max_depth
+func lockTagsForReparent(log *logger.BLogger, tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), `LOCK TABLE tags IN SHARE ROW EXCLUSIVE MODE`)
+	if err != nil {
+		log.Errorf("failed to lock tags: %v", err)
+		return err
+	}
+	return nil
+}
+
+// getAllTags returns every tag, for building the tag tree
+func getAllTags(log *logger.BLogger) ([]Tag, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT
+				id,
+				created_at,
+				updated_at,
+				title,
+				description,
+				parent_id,
+				is_parent,
+				edited
+				FROM tags`,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags = []Tag{}
+	for rows.Next() {
+		var id, title, desc string
+		var cAt, uAt time.Time
+		var parentID *string
+		var isParent, edited bool
+		rows.Scan(&id, &cAt, &uAt, &title, &desc, &parentID, &isParent, &edited)
+		tags = append(tags, Tag{id, cAt, uAt, title, desc, parentID, isParent, edited})
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,212 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/bschlaman/todo-app/database"
+)
+
+func GetTaskRelationships(log *logger.BLogger) ([]TaskRelationship, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT
+				id,
+				created_at,
+				task_id_a,
+				task_id_b,
+				relation
+				FROM task_relationships`,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var taskRelationships = []TaskRelationship{}
+	for rows.Next() {
+		var id int
+		var taskIDA, taskIDB, relation string
+		var cAt time.Time
+		rows.Scan(&id, &cAt, &taskIDA, &taskIDB, &relation)
+		taskRelationships = append(taskRelationships, TaskRelationship{id, cAt, taskIDA, taskIDB, relation})
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	return taskRelationships, nil
+}
+
+// CreateTaskRelationship relates task A to task B.
+// A BLOCKS relationship which would close a cycle of BLOCKS relationships is rejected.
+func CreateTaskRelationship(log *logger.BLogger, createReq CreateTaskRelationshipReq) (*TaskRelationship, error) {
+	if createReq.TaskIDA == "" || createReq.TaskIDB == "" || createReq.Relation == "" {
+		log.Error("createTaskRelationship: parameter(s) blank")
+		return nil, InputError{}
+	}
+	if !taskRelations[createReq.Relation] {
+		log.Errorf("createTaskRelationship: invalid relation %s", createReq.Relation)
+		return nil, InputError{}
+	}
+	if createReq.TaskIDA == createReq.TaskIDB {
+		log.Error("createTaskRelationship: a task cannot be related to itself")
+		return nil, InputError{}
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	if createReq.Relation == "BLOCKS" {
+		// serialize with other relationship creation so two edges cannot close a cycle together
+		_, err = tx.Exec(context.Background(), `LOCK TABLE task_relationships IN SHARE ROW EXCLUSIVE MODE`)
+		if err != nil {
+			log.Errorf("failed to lock task_relationships: %v", err)
+			return nil, err
+		}
+		if err := checkTaskDependencyCycle(log, tx, createReq.TaskIDA, createReq.TaskIDB); err != nil {
+			return nil, err
+		}
+	}
+
+	var id int
+	var taskIDA, taskIDB, relation string
+	var cAt time.Time
+
+	err = tx.QueryRow(context.Background(),
+		`INSERT INTO task_relationships (
+				task_id_a,
+				task_id_b,
+				relation
+			) VALUES (
+				$1,
+				$2,
+				$3
+			) RETURNING
+				id,
+				created_at,
+				task_id_a,
+				task_id_b,
+				relation`,
+		createReq.TaskIDA,
+		createReq.TaskIDB,
+		createReq.Relation,
+	).Scan(&id, &cAt, &taskIDA, &taskIDB, &relation)
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return nil, err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return nil, err
+	}
+
+	return &TaskRelationship{id, cAt, taskIDA, taskIDB, relation}, nil
+}
+
+func DestroyTaskRelationshipByID(log *logger.BLogger, destroyReq DestroyTaskRelationshipByIDReq) error {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return err
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(context.Background(),
+		`DELETE FROM task_relationships WHERE id = $1`,
+		destroyReq.ID,
+	)
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		log.Errorf("destroyTaskRelationshipByID: no task relationship with id %d", destroyReq.ID)
+		return InputError{}
+	}
+
+	return nil
+}
+
+// GetTaskBlockers returns the live tasks which directly block a task
+func GetTaskBlockers(log *logger.BLogger, taskID string) ([]Task, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	return queryTaskBlockers(log, conn, taskID, false)
+}
+
+// queryTaskBlockers returns the live tasks which directly block a task.
+// If unfinishedOnly, tasks which are DONE, ARCHIVE, DUPLICATE or DEPRIORITIZED are left out.
+func queryTaskBlockers(log *logger.BLogger, q queryer, taskID string, unfinishedOnly bool) ([]Task, error) {
+	rows, err := q.Query(context.Background(),
+		`SELECT
+				t.id,
+				t.sqid,
+				t.created_at,
+				t.updated_at,
+				t.title,
+				t.description,
+				t.status,
+				t.story_id,
+				t.bucket_id,
+				t.edited,
+				t.bulk_task
+				FROM task_relationships r
+				JOIN tasks t ON r.task_id_a = t.id
+				WHERE r.relation = 'BLOCKS'
+				AND r.task_id_b = $1
+				AND t.deleted_at IS NULL
+				AND (NOT $2 OR t.status NOT IN ('DONE', 'ARCHIVE', 'DUPLICATE', 'DEPRIORITIZED'))
+				ORDER BY t.created_at`,
+		taskID,
+		unfinishedOnly,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks = []Task{}
+	for rows.Next() {
+		var id, sqid, title, desc, status string
+		var storyID, bucketID *string
+		var cAt, uAt time.Time
+		var edited, bulkTask bool
+		rows.Scan(&id, &sqid, &cAt, &uAt, &title, &desc, &status, &storyID, &bucketID, &edited, &bulkTask)
+		tasks = append(tasks, Task{id, sqid, cAt, uAt, title, desc, status, storyID, bucketID, edited, bulkTask, nil, nil})
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	return tasks, nil
+}
@@ -0,0 +1,158 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/bschlaman/todo-app/database"
+)
+
+// Sources of a task's tag, as returned by GetTaskTags
+const (
+	TaskTagSourceTask   = "task"
+	TaskTagSourceStory  = "story"
+	TaskTagSourceBucket = "bucket"
+)
+
+func GetTaskTagAssignments(log *logger.BLogger) ([]TaskTagAssignment, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT id, created_at, tag_id, task_id FROM task_tag_assignments`,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments = []TaskTagAssignment{}
+	for rows.Next() {
+		var id int
+		var tagID, taskID string
+		var cAt time.Time
+		rows.Scan(&id, &cAt, &tagID, &taskID)
+		assignments = append(assignments, TaskTagAssignment{id, cAt, tagID, taskID})
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	return assignments, nil
+}
+
+func CreateTaskTagAssignment(log *logger.BLogger, createReq CreateTaskTagAssignmentReq) (*TaskTagAssignment, error) {
+	if createReq.TagID == "" || createReq.TaskID == "" {
+		log.Error("createTaskTagAssignment: TagID or TaskID blank")
+		return nil, InputError{}
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	var id int
+	var tagID, taskID string
+	var cAt time.Time
+
+	err = conn.QueryRow(context.Background(),
+		`INSERT INTO task_tag_assignments (tag_id, task_id)
+			VALUES ($1, $2)
+			RETURNING id, created_at, tag_id, task_id`,
+		createReq.TagID,
+		createReq.TaskID,
+	).Scan(&id, &cAt, &tagID, &taskID)
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return nil, err
+	}
+
+	return &TaskTagAssignment{id, cAt, tagID, taskID}, nil
+}
+
+func DestroyTaskTagAssignmentByID(log *logger.BLogger, destroyReq DestroyTaskTagAssignmentByIDReq) error {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(context.Background(),
+		`DELETE FROM task_tag_assignments WHERE id = $1`,
+		destroyReq.ID,
+	)
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetTaskTags returns the tags of the tasks which are not in the trash, or of
+// a single task if taskID is not blank.  With inherit, a task also carries the
+// tags of its story and of its bucket.  A tag reaching a task more than once is
+// returned once, from the task itself if it is assigned there, else from the story.
+func GetTaskTags(log *logger.BLogger, taskID string, inherit bool) ([]TaskTag, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT DISTINCT ON (task_id, tag_id) task_id, tag_id, source
+			FROM (
+				SELECT tta.task_id, tta.tag_id, 'task' AS source, 1 AS priority
+					FROM task_tag_assignments tta
+				UNION ALL
+				SELECT t.id, ta.tag_id, 'story', 2
+					FROM tasks t
+					JOIN tag_assignments ta ON ta.story_id = t.story_id
+					WHERE $2
+				UNION ALL
+				SELECT t.id, bta.tag_id, 'bucket', 3
+					FROM tasks t
+					JOIN bucket_tag_assignments bta ON bta.bucket_id = t.bucket_id
+					WHERE $2
+			) task_tags
+			WHERE task_id IN (
+				SELECT id FROM tasks
+					WHERE deleted_at IS NULL
+					AND ($1 = '' OR id::text = $1)
+			)
+			ORDER BY task_id, tag_id, priority`,
+		taskID,
+		inherit,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var taskTags = []TaskTag{}
+	for rows.Next() {
+		var tID, tagID, source string
+		rows.Scan(&tID, &tagID, &source)
+		taskTags = append(taskTags, TaskTag{tID, tagID, source})
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	return taskTags, nil
+}
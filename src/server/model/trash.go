@@ -0,0 +1,206 @@
+package model
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/bschlaman/todo-app/database"
+)
+
+// kinds of entities which can be soft deleted
+const (
+	TrashKindTask    = "task"
+	TrashKindStory   = "story"
+	TrashKindBucket  = "bucket"
+	TrashKindComment = "comment"
+)
+
+// GetTrash returns every soft deleted entity, most recently deleted first
+func GetTrash(log *logger.BLogger) ([]TrashItem, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(context.Background(),
+		`SELECT 'task', id::text, sqid, title, deleted_at
+				FROM tasks WHERE deleted_at IS NOT NULL
+			UNION ALL
+			SELECT 'story', id::text, sqid, title, deleted_at
+				FROM stories WHERE deleted_at IS NOT NULL
+			UNION ALL
+			SELECT 'bucket', id::text, sqid, title, deleted_at
+				FROM buckets WHERE deleted_at IS NOT NULL
+			UNION ALL
+			SELECT 'comment', id::text, NULL, left(text, 150), deleted_at
+				FROM comments WHERE deleted_at IS NOT NULL
+			ORDER BY deleted_at DESC`,
+	)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trashItems = []TrashItem{}
+	for rows.Next() {
+		var kind, id, title string
+		var sqid *string
+		var dAt time.Time
+		rows.Scan(&kind, &id, &sqid, &title, &dAt)
+		trashItems = append(trashItems, TrashItem{kind, id, sqid, title, dAt})
+	}
+	if rows.Err() != nil {
+		log.Errorf("Query failed: %v", rows.Err())
+		return nil, rows.Err()
+	}
+
+	return trashItems, nil
+}
+
+// RestoreEntity takes a soft deleted entity back out of the trash
+func RestoreEntity(log *logger.BLogger, restoreReq RestoreEntityReq) error {
+	var query string
+	var id interface{} = restoreReq.ID
+	switch restoreReq.Kind {
+	case TrashKindTask:
+		query = `UPDATE tasks SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+	case TrashKindStory:
+		query = `UPDATE stories SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+	case TrashKindBucket:
+		query = `UPDATE buckets SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+	case TrashKindComment:
+		commentID, err := strconv.Atoi(restoreReq.ID)
+		if err != nil {
+			log.Errorf("restoreEntity: invalid comment id: %s", restoreReq.ID)
+			return InputError{}
+		}
+		id = commentID
+		query = `UPDATE comments SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+	default:
+		log.Errorf("restoreEntity: unknown kind: %s", restoreReq.Kind)
+		return InputError{}
+	}
+
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	tag, err := tx.Exec(context.Background(), query, id)
+	if err != nil {
+		log.Errorf("conn.Exec failed: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		log.Errorf("restoreEntity: no %s with id %s in the trash", restoreReq.Kind, restoreReq.ID)
+		return InputError{}
+	}
+
+	// trash kinds double as history kinds, except that buckets have no history
+	if restoreReq.Kind != TrashKindBucket {
+		if err := recordHistory(log, tx, restoreReq.Kind, restoreReq.ID, HistoryActionRestore, nil); err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// PurgeDeletedEntities permanently deletes every entity which was
+// soft deleted before deletedBefore, along with its dependent rows.
+// Returns the number of entities purged.
+func PurgeDeletedEntities(log *logger.BLogger, deletedBefore time.Time) (int64, error) {
+	conn, err := database.GetPgxConn()
+	if err != nil {
+		log.Errorf("unable to connect to database: %v", err)
+		return 0, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Errorf("failed to begin transaction: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback(context.Background())
+
+	// statements which only clean up dependent rows are not counted
+	stmts := []struct {
+		sql   string
+		count bool
+	}{
+		// history goes with its entity
+		{`DELETE FROM entity_history
+			WHERE (entity_kind = 'comment' AND entity_id IN (
+				SELECT id::text FROM comments
+				WHERE deleted_at < $1
+				OR task_id IN (SELECT id FROM tasks WHERE deleted_at < $1)))
+			OR (entity_kind = 'task' AND entity_id IN (
+				SELECT id::text FROM tasks WHERE deleted_at < $1))
+			OR (entity_kind = 'story' AND entity_id IN (
+				SELECT id::text FROM stories WHERE deleted_at < $1))`, false},
+		// comments go with their task
+		{`DELETE FROM comments
+			WHERE deleted_at < $1
+			OR task_id IN (SELECT id FROM tasks WHERE deleted_at < $1)`, true},
+		{`DELETE FROM task_tag_assignments
+			WHERE task_id IN (SELECT id FROM tasks WHERE deleted_at < $1)`, false},
+		{`DELETE FROM checklist_items
+			WHERE task_id IN (SELECT id FROM tasks WHERE deleted_at < $1)`, false},
+		{`DELETE FROM tasks WHERE deleted_at < $1`, true},
+		// any task still pointing at a purged story or bucket is detached
+		{`UPDATE tasks SET story_id = NULL
+			WHERE story_id IN (SELECT id FROM stories WHERE deleted_at < $1)`, false},
+		{`DELETE FROM tag_assignments
+			WHERE story_id IN (SELECT id FROM stories WHERE deleted_at < $1)`, false},
+		{`DELETE FROM story_relationships
+			WHERE story_id_a IN (SELECT id FROM stories WHERE deleted_at < $1)
+			OR story_id_b IN (SELECT id FROM stories WHERE deleted_at < $1)`, false},
+		{`DELETE FROM stories WHERE deleted_at < $1`, true},
+		{`UPDATE tasks SET bucket_id = NULL
+			WHERE bucket_id IN (SELECT id FROM buckets WHERE deleted_at < $1)`, false},
+		{`DELETE FROM bucket_tag_assignments
+			WHERE bucket_id IN (SELECT id FROM buckets WHERE deleted_at < $1)`, false},
+		{`DELETE FROM buckets WHERE deleted_at < $1`, true},
+	}
+
+	var purged int64
+	for _, stmt := range stmts {
+		tag, err := tx.Exec(context.Background(), stmt.sql, deletedBefore)
+		if err != nil {
+			log.Errorf("purge failed: %v", err)
+			return 0, err
+		}
+		if stmt.count {
+			purged += tag.RowsAffected()
+		}
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		log.Errorf("failed to commit transaction: %v", err)
+		return 0, err
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,355 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/jackc/pgx/v4"
+)
+
+// queryRower is satisfied by both pooled connections and transactions
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// queryer is satisfied by both pooled connections and transactions
+type queryer interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+// lengthCheck pairs a value with the config table key holding its max length
+type lengthCheck struct {
+	configKey string
+	value     string
+}
+
+// checkMaxLens returns an InputError if any value is longer than the
+// max length stored in the config table.  Missing keys are not enforced.
+func checkMaxLens(log *logger.BLogger, checks ...lengthCheck) error {
+	serverConfig, err := GetConfig(log)
+	if err != nil {
+		return err
+	}
+	for _, c := range checks {
+		maxLen, ok := serverConfig[c.configKey].(int64)
+		if !ok {
+			continue
+		}
+		if int64(utf8.RuneCountInString(c.value)) > maxLen {
+			log.Errorf("%s exceeded: %d > %d", c.configKey, utf8.RuneCountInString(c.value), maxLen)
+			return InputError{}
+		}
+	}
+	return nil
+}
+
+// parseSprintDates validates a sprint's date strings and their order
+func parseSprintDates(log *logger.BLogger, start, end string) (time.Time, time.Time, error) {
+	startDate, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		log.Infof("invalid sprint start date: %v", start)
+		return time.Time{}, time.Time{}, InputError{}
+	}
+	endDate, err := time.Parse("2006-01-02", end)
+	if err != nil {
+		log.Infof("invalid sprint end date: %v", end)
+		return time.Time{}, time.Time{}, InputError{}
+	}
+	if !startDate.Before(endDate) {
+		log.Infof("invalid sprint range: start=%s end=%s", start, end)
+		return time.Time{}, time.Time{}, InputError{}
+	}
+	return startDate, endDate, nil
+}
+
+// checkSprintOverlap returns an InputError if any sprint other than excludeID
+// shares a day with the range startDate to endDate.  Both ends are inclusive.
+func checkSprintOverlap(log *logger.BLogger, q queryRower, startDate, endDate time.Time, excludeID string) error {
+	var overlapping *string
+	err := q.QueryRow(context.Background(),
+		`SELECT title FROM sprints
+			WHERE start_date <= $2::date AND end_date >= $1::date
+			AND id::text <> $3
+			LIMIT 1`,
+		startDate.Format("2006-01-02"),
+		endDate.Format("2006-01-02"),
+		excludeID,
+	).Scan(&overlapping)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return err
+	}
+	log.Errorf("sprint %s - %s overlaps sprint %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), *overlapping)
+	return InputError{}
+}
+
+// checkSprintTitleFree returns an InputError if a sprint other than excludeID
+// already has title, which the unique constraint on sprints.title would reject
+func checkSprintTitleFree(log *logger.BLogger, q queryRower, title, excludeID string) error {
+	var taken bool
+	err := q.QueryRow(context.Background(),
+		`SELECT EXISTS (SELECT 1 FROM sprints WHERE title = $1 AND id::text <> $2)`,
+		title,
+		excludeID,
+	).Scan(&taken)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return err
+	}
+	if taken {
+		log.Errorf("a sprint titled %q already exists", title)
+		return InputError{}
+	}
+	return nil
+}
+
+// bucketStatusTransitions lists the statuses a bucket may move to from each status
+var bucketStatusTransitions = map[string][]string{
+	"ACTIVE":   {"INACTIVE", "ARCHIVED"},
+	"INACTIVE": {"ACTIVE", "ARCHIVED"},
+	"ARCHIVED": {"ACTIVE"},
+}
+
+// validBucketStatusTransition reports whether a bucket may move from one status to another
+func validBucketStatusTransition(from, to string) bool {
+	if _, ok := bucketStatusTransitions[to]; !ok {
+		return false
+	}
+	if from == to {
+		return true
+	}
+	for _, s := range bucketStatusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// nilIfBlank treats an empty id the same as a null one
+func nilIfBlank(id *string) *string {
+	if id == nil || *id == "" {
+		return nil
+	}
+	return id
+}
+
+// validateTaskParent checks that a task belongs to at most one of a story or a bucket,
+// and that the parent exists.  This mirrors the tasks table CHECK constraint
+// so the client gets an InputError rather than a constraint violation.
+func validateTaskParent(log *logger.BLogger, q queryRower, storyID, bucketID *string) error {
+	if storyID != nil && bucketID != nil {
+		log.Errorf("task cannot belong to both story %s and bucket %s", *storyID, *bucketID)
+		return InputError{}
+	}
+
+	var exists bool
+	var err error
+	switch {
+	case storyID != nil:
+		err = q.QueryRow(context.Background(),
+			`SELECT EXISTS (SELECT 1 FROM stories WHERE id::text = $1 AND deleted_at IS NULL)`,
+			*storyID,
+		).Scan(&exists)
+	case bucketID != nil:
+		err = q.QueryRow(context.Background(),
+			`SELECT EXISTS (SELECT 1 FROM buckets WHERE id::text = $1 AND deleted_at IS NULL)`,
+			*bucketID,
+		).Scan(&exists)
+	default:
+		return nil
+	}
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return err
+	}
+	if !exists {
+		log.Error("task parent does not exist")
+		return InputError{}
+	}
+
+	return nil
+}
+
+// taskStatuses mirrors the task_status enum
+var taskStatuses = map[string]bool{
+	"BACKLOG":         true,
+	"DOING":           true,
+	"DONE":            true,
+	"DEPRIORITIZED":   true,
+	"ARCHIVE":         true,
+	"DUPLICATE":       true,
+	"DEADLINE PASSED": true,
+}
+
+// storyStatuses mirrors the story_status enum
+var storyStatuses = map[string]bool{
+	"BACKLOG":         true,
+	"DOING":           true,
+	"DONE":            true,
+	"DEPRIORITIZED":   true,
+	"ARCHIVE":         true,
+	"DUPLICATE":       true,
+	"DEADLINE PASSED": true,
+}
+
+// storyRelations mirrors the relationship enum
+var storyRelations = map[string]bool{
+	"CONTINUED_BY": true,
+	"BLOCKS":       true,
+	"RELATES_TO":   true,
+	"DUPLICATES":   true,
+	"PARENT_OF":    true,
+}
+
+// dependencyRelations are the story relations which must not form a cycle
+var dependencyRelations = map[string]bool{
+	"BLOCKS":    true,
+	"PARENT_OF": true,
+}
+
+// checkStoryDependencyCycle returns an InputError if story A is reachable from
+// story B through BLOCKS and PARENT_OF relationships, in which case
+// relating A to B by either would form a cycle
+func checkStoryDependencyCycle(log *logger.BLogger, q queryRower, storyIDA, storyIDB string) error {
+	var cycle bool
+	err := q.QueryRow(context.Background(),
+		`WITH RECURSIVE reachable(story_id) AS (
+				SELECT $2::uuid
+				UNION
+				SELECT r.story_id_b
+					FROM story_relationships r
+					JOIN reachable ON r.story_id_a = reachable.story_id
+					WHERE r.relation IN ('BLOCKS', 'PARENT_OF')
+			)
+			SELECT EXISTS (SELECT 1 FROM reachable WHERE story_id = $1::uuid)`,
+		storyIDA,
+		storyIDB,
+	).Scan(&cycle)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return err
+	}
+	if cycle {
+		log.Errorf("relating story %s to %s would form a dependency cycle", storyIDA, storyIDB)
+		return InputError{}
+	}
+	return nil
+}
+
+// taskRelations mirrors the task_relationship enum
+var taskRelations = map[string]bool{
+	"BLOCKS":     true,
+	"RELATES_TO": true,
+	"DUPLICATES": true,
+}
+
+// checkTaskDependencyCycle returns an InputError if task A is reachable
+// from task B through BLOCKS relationships, in which case
+// task A blocking task B would form a cycle
+func checkTaskDependencyCycle(log *logger.BLogger, q queryRower, taskIDA, taskIDB string) error {
+	var cycle bool
+	err := q.QueryRow(context.Background(),
+		`WITH RECURSIVE reachable(task_id) AS (
+				SELECT $2::uuid
+				UNION
+				SELECT r.task_id_b
+					FROM task_relationships r
+					JOIN reachable ON r.task_id_a = reachable.task_id
+					WHERE r.relation = 'BLOCKS'
+			)
+			SELECT EXISTS (SELECT 1 FROM reachable WHERE task_id = $1::uuid)`,
+		taskIDA,
+		taskIDB,
+	).Scan(&cycle)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return err
+	}
+	if cycle {
+		log.Errorf("task %s blocking %s would form a dependency cycle", taskIDA, taskIDB)
+		return InputError{}
+	}
+	return nil
+}
+
+// defaultTagMaxDepth is used if the config table has no tag_max_depth
+const defaultTagMaxDepth = 4
+
+// checkTagParent returns an InputError if parentID is not a tag, or if
+// putting tagID under it would form a cycle or nest tags deeper than
+// tag_max_depth.  tagID is blank for a tag which is being created.
+func checkTagParent(log *logger.BLogger, q queryRower, tagID, parentID string) error {
+	serverConfig, err := GetConfig(log)
+	if err != nil {
+		return err
+	}
+	maxDepth, ok := serverConfig["tag_max_depth"].(int64)
+	if !ok {
+		maxDepth = defaultTagMaxDepth
+	}
+
+	// walk up from the parent; the depth bound guards against cycles in existing rows
+	var parentDepth int
+	var cycle bool
+	err = q.QueryRow(context.Background(),
+		`WITH RECURSIVE ancestors(id, parent_id, depth) AS (
+				SELECT id, parent_id, 1 FROM tags WHERE id = $1
+				UNION ALL
+				SELECT t.id, t.parent_id, a.depth + 1
+					FROM tags t
+					JOIN ancestors a ON t.id = a.parent_id
+					WHERE a.depth <= $3
+			)
+			SELECT COUNT(*), COALESCE(BOOL_OR(id::text = $2), false) FROM ancestors`,
+		parentID,
+		tagID,
+		maxDepth,
+	).Scan(&parentDepth, &cycle)
+	if err != nil {
+		log.Errorf("Query failed: %v", err)
+		return err
+	}
+	if parentDepth == 0 {
+		log.Errorf("parent tag %s does not exist", parentID)
+		return InputError{}
+	}
+	if cycle {
+		log.Errorf("putting tag %s under %s would form a cycle", tagID, parentID)
+		return InputError{}
+	}
+
+	// the tag brings its own descendants along with it
+	height := 1
+	if tagID != "" {
+		err = q.QueryRow(context.Background(),
+			`WITH RECURSIVE subtree(id, depth) AS (
+					SELECT id, 1 FROM tags WHERE id = $1
+					UNION ALL
+					SELECT t.id, s.depth + 1
+						FROM tags t
+						JOIN subtree s ON t.parent_id = s.id
+						WHERE s.depth <= $2
+				)
+				SELECT COALESCE(MAX(depth), 1) FROM subtree`,
+			tagID,
+			maxDepth,
+		).Scan(&height)
+		if err != nil {
+			log.Errorf("Query failed: %v", err)
+			return err
+		}
+	}
+
+	if int64(parentDepth+height) > maxDepth {
+		log.Errorf("putting tag %s under %s would nest tags deeper than %d", tagID, parentID, maxDepth)
+		return InputError{}
+	}
+	return nil
+}
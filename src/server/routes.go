@@ -36,61 +36,119 @@ func registerAPIHandlers() {
 	}
 
 	// TODO (2022.11.29): should this mapping be part of config, or at the very least the model?
+	// kinds is shorthand for the Reads and Writes columns below
+	kinds := func(k ...string) []string { return k }
+	ek := EntityKind
+
 	apiRoutes := []struct {
 		Path    string
 		Handler func() http.Handler
 		APIName string
 		APIType string
+		// entity kinds which the api reads (cached GETs are tagged with these)
+		// and writes (a successful call evicts cached GETs which read them)
+		Reads  []string
+		Writes []string
 	}{
-		{"/api/echo", utils.EchoHandle, "Echo", APIType.Util},
-		{"/api/echodelay", utils.EchoDelayHandle, "EchoDelay", APIType.Util},
-		{"/api/login", loginHandle, "Login", APIType.Auth},
-		{"/api/check_session", checkSessionHandle, "CheckSession", APIType.Auth},
-		{"/api/get_config", getConfigHandle, "GetConfig", APIType.Get},
+		{"/api/echo", utils.EchoHandle, "Echo", APIType.Util, nil, nil},
+		{"/api/echodelay", utils.EchoDelayHandle, "EchoDelay", APIType.Util, nil, nil},
+		{"/api/login", loginHandle, "Login", APIType.Auth, nil, nil},
+		{"/api/check_session", checkSessionHandle, "CheckSession", APIType.Auth, nil, nil},
+		{"/api/get_config", getConfigHandle, "GetConfig", APIType.Get, kinds(ek.Config), nil},
+		{"/api/get_cache_stats", getCacheStatsHandle, "GetCacheStats", APIType.Util, nil, nil},
 		// tasks
-		{"/api/get_tasks", getTasksHandle, "GetTasks", APIType.GetMany},
-		{"/api/get_task", getTaskByIDHandle, "GetTaskByID", APIType.Get},
-		{"/api/put_task", putTaskHandle, "PutTask", APIType.Put},
-		{"/api/create_task", createTaskHandle, "CreateTask", APIType.Create},
+		{"/api/get_tasks", getTasksHandle, "GetTasks", APIType.GetMany, kinds(ek.Task, ek.Comment, ek.Story, ek.Tag, ek.TagAssignment, ek.BucketTagAssignment, ek.TaskTagAssignment, ek.ChecklistItem), nil},
+		{"/api/get_task", getTaskByIDHandle, "GetTaskByID", APIType.Get, kinds(ek.Task, ek.TaskRelationship, ek.ChecklistItem), nil},
+		{"/api/put_task", putTaskHandle, "PutTask", APIType.Put, nil, kinds(ek.Task)},
+		{"/api/patch_task", patchTaskHandle, "PatchTask", APIType.Put, nil, kinds(ek.Task)},
+		{"/api/create_task", createTaskHandle, "CreateTask", APIType.Create, nil, kinds(ek.Task)},
+		{"/api/destroy_task", destroyTaskHandle, "DestroyTask", APIType.Destroy, nil, kinds(ek.Task, ek.Comment)},
 		// comments
-		{"/api/create_comment", createCommentHandle, "CreateComment", APIType.Create},
-		{"/api/put_comment", putCommentHandle, "PutComment", APIType.Put},
-		{"/api/get_comments_by_task_id", getCommentsByTaskIDHandle, "GetCommentsByTaskID", APIType.GetMany},
+		{"/api/create_comment", createCommentHandle, "CreateComment", APIType.Create, nil, kinds(ek.Comment)},
+		{"/api/put_comment", putCommentHandle, "PutComment", APIType.Put, nil, kinds(ek.Comment)},
+		{"/api/destroy_comment", destroyCommentHandle, "DestroyComment", APIType.Destroy, nil, kinds(ek.Comment)},
+		{"/api/get_comments_by_task_id", getCommentsByTaskIDHandle, "GetCommentsByTaskID", APIType.GetMany, kinds(ek.Comment), nil},
+		// checklist_items
+		{"/api/get_checklist_items", getChecklistItemsHandle, "GetChecklistItems", APIType.GetMany, kinds(ek.ChecklistItem), nil},
+		{"/api/create_checklist_item", createChecklistItemHandle, "CreateChecklistItem", APIType.Create, nil, kinds(ek.ChecklistItem)},
+		{"/api/put_checklist_item", putChecklistItemHandle, "PutChecklistItem", APIType.Put, nil, kinds(ek.ChecklistItem)},
+		{"/api/reorder_checklist_items", reorderChecklistItemsHandle, "ReorderChecklistItems", APIType.Put, nil, kinds(ek.ChecklistItem)},
+		{"/api/destroy_checklist_item", destroyChecklistItemHandle, "DestroyChecklistItem", APIType.Destroy, nil, kinds(ek.ChecklistItem)},
 		// stories
-		{"/api/get_stories", getStoriesHandle, "GetStories", APIType.GetMany},
-		{"/api/get_story", getStoryByIDHandle, "GetStoryByID", APIType.Get},
-		{"/api/create_story", createStoryHandle, "CreateStory", APIType.Create},
-		{"/api/put_story", putStoryHandle, "PutStory", APIType.Put},
+		{"/api/get_stories", getStoriesHandle, "GetStories", APIType.GetMany, kinds(ek.Story, ek.Tag, ek.TagAssignment), nil},
+		{"/api/get_story", getStoryByIDHandle, "GetStoryByID", APIType.Get, kinds(ek.Story), nil},
+		{"/api/create_story", createStoryHandle, "CreateStory", APIType.Create, nil, kinds(ek.Story)},
+		{"/api/put_story", putStoryHandle, "PutStory", APIType.Put, nil, kinds(ek.Story)},
+		{"/api/patch_story", patchStoryHandle, "PatchStory", APIType.Put, nil, kinds(ek.Story)},
+		{"/api/destroy_story", destroyStoryHandle, "DestroyStory", APIType.Destroy, nil, kinds(ek.Story, ek.TagAssignment, ek.StoryRelationship)},
 		// sprints
-		{"/api/get_sprints", getSprintsHandle, "GetSprints", APIType.GetMany},
-		{"/api/create_sprint", createSprintHandle, "CreateSprint", APIType.Create},
+		{"/api/get_sprints", getSprintsHandle, "GetSprints", APIType.GetMany, kinds(ek.Sprint), nil},
+		{"/api/get_current_sprint", getCurrentSprintHandle, "GetCurrentSprint", APIType.Get, kinds(ek.Sprint), nil},
+		{"/api/get_sprint_calendar_issues", getSprintCalendarIssuesHandle, "GetSprintCalendarIssues", APIType.GetMany, kinds(ek.Sprint), nil},
+		{"/api/create_sprint", createSprintHandle, "CreateSprint", APIType.Create, nil, kinds(ek.Sprint)},
+		{"/api/put_sprint", putSprintHandle, "PutSprint", APIType.Put, nil, kinds(ek.Sprint)},
+		{"/api/destroy_sprint", destroySprintHandle, "DestroySprint", APIType.Destroy, nil, kinds(ek.Sprint)},
+		{"/api/rollover_sprint", rolloverSprintHandle, "RolloverSprint", APIType.Create, nil, kinds(ek.Sprint, ek.Story, ek.Task, ek.TagAssignment, ek.StoryRelationship, ek.TaskTagAssignment, ek.ChecklistItem)},
 		// tag_assignments
-		{"/api/get_tag_assignments", getTagAssignmentsHandle, "GetTagAssignments", APIType.GetMany},
-		{"/api/create_tag_assignment", createTagAssignmentHandle, "CreateTagAssignment", APIType.Create},
-		{"/api/destroy_tag_assignment", destroyTagAssignmentHandle, "DestroyTagAssignment", APIType.Destroy},
-		{"/api/destroy_tag_assignment_by_id", destroyTagAssignmentByIDHandle, "DestroyTagAssignmentByID", APIType.Destroy},
+		{"/api/get_tag_assignments", getTagAssignmentsHandle, "GetTagAssignments", APIType.GetMany, kinds(ek.TagAssignment), nil},
+		{"/api/create_tag_assignment", createTagAssignmentHandle, "CreateTagAssignment", APIType.Create, nil, kinds(ek.TagAssignment)},
+		{"/api/destroy_tag_assignment", destroyTagAssignmentHandle, "DestroyTagAssignment", APIType.Destroy, nil, kinds(ek.TagAssignment)},
+		{"/api/destroy_tag_assignment_by_id", destroyTagAssignmentByIDHandle, "DestroyTagAssignmentByID", APIType.Destroy, nil, kinds(ek.TagAssignment)},
 		// tags
-		{"/api/get_tags", getTagsHandle, "GetTags", APIType.GetMany},
-		{"/api/create_tag", createTagHandle, "CreateTag", APIType.Create},
+		{"/api/get_tags", getTagsHandle, "GetTags", APIType.GetMany, kinds(ek.Tag), nil},
+		{"/api/create_tag", createTagHandle, "CreateTag", APIType.Create, nil, kinds(ek.Tag)},
+		{"/api/put_tag", putTagHandle, "PutTag", APIType.Put, nil, kinds(ek.Tag)},
+		{"/api/destroy_tag", destroyTagHandle, "DestroyTag", APIType.Destroy, nil, kinds(ek.Tag, ek.TagAssignment, ek.BucketTagAssignment, ek.TaskTagAssignment)},
 		// story_relationships
-		{"/api/get_story_relationships", getStoryRelationshipsHandle, "GetStoryRelationships", APIType.GetMany},
-		{"/api/create_story_relationship", createStoryRelationshipHandle, "CreateStoryRelationship", APIType.Create},
-		{"/api/destroy_story_relationship", destroyStoryRelationshipByIDHandle, "DestroyStoryRelationship", APIType.Destroy},
+		{"/api/get_story_relationships", getStoryRelationshipsHandle, "GetStoryRelationships", APIType.GetMany, kinds(ek.StoryRelationship), nil},
+		{"/api/get_story_graph", getStoryGraphHandle, "GetStoryGraph", APIType.Get, kinds(ek.StoryRelationship, ek.Story), nil},
+		{"/api/create_story_relationship", createStoryRelationshipHandle, "CreateStoryRelationship", APIType.Create, nil, kinds(ek.StoryRelationship)},
+		{"/api/destroy_story_relationship", destroyStoryRelationshipByIDHandle, "DestroyStoryRelationship", APIType.Destroy, nil, kinds(ek.StoryRelationship)},
+		// task_relationships
+		{"/api/get_task_relationships", getTaskRelationshipsHandle, "GetTaskRelationships", APIType.GetMany, kinds(ek.TaskRelationship), nil},
+		{"/api/create_task_relationship", createTaskRelationshipHandle, "CreateTaskRelationship", APIType.Create, nil, kinds(ek.TaskRelationship)},
+		{"/api/destroy_task_relationship", destroyTaskRelationshipByIDHandle, "DestroyTaskRelationship", APIType.Destroy, nil, kinds(ek.TaskRelationship)},
 		// uploads
-		{"/api/upload_image", uploadImageHandle, "UploadImage", APIType.Upload},
+		{"/api/upload_image", uploadImageHandle, "UploadImage", APIType.Upload, nil, kinds(ek.Upload)},
 		// buckets
-		{"/api/get_buckets", getBucketsHandle, "GetBuckets", APIType.GetMany},
-		{"/api/create_bucket", createBucketHandle, "CreateBucket", APIType.Create},
+		{"/api/get_buckets", getBucketsHandle, "GetBuckets", APIType.GetMany, kinds(ek.Bucket, ek.Tag, ek.BucketTagAssignment), nil},
+		{"/api/get_bucket", getBucketByIDHandle, "GetBucketByID", APIType.Get, kinds(ek.Bucket, ek.Task, ek.Comment), nil},
+		{"/api/create_bucket", createBucketHandle, "CreateBucket", APIType.Create, nil, kinds(ek.Bucket)},
+		{"/api/put_bucket", putBucketHandle, "PutBucket", APIType.Put, nil, kinds(ek.Bucket)},
+		{"/api/destroy_bucket", destroyBucketHandle, "DestroyBucket", APIType.Destroy, nil, kinds(ek.Bucket)},
 		// bucket_tag_assignments
-		{"/api/get_bucket_tag_assignments", getBucketTagAssignmentsHandle, "GetBucketTagAssignments", APIType.GetMany},
-		{"/api/create_bucket_tag_assignment", createBucketTagAssignmentHandle, "CreateBucketTagAssignment", APIType.Create},
-		{"/api/destroy_bucket_tag_assignment_by_id", destroyBucketTagAssignmentByIDHandle, "DestroyBucketTagAssignmentByID", APIType.Destroy},
+		{"/api/get_bucket_tag_assignments", getBucketTagAssignmentsHandle, "GetBucketTagAssignments", APIType.GetMany, kinds(ek.BucketTagAssignment), nil},
+		{"/api/create_bucket_tag_assignment", createBucketTagAssignmentHandle, "CreateBucketTagAssignment", APIType.Create, nil, kinds(ek.BucketTagAssignment)},
+		{"/api/destroy_bucket_tag_assignment_by_id", destroyBucketTagAssignmentByIDHandle, "DestroyBucketTagAssignmentByID", APIType.Destroy, nil, kinds(ek.BucketTagAssignment)},
+		// task_tag_assignments
+		{"/api/get_task_tag_assignments", getTaskTagAssignmentsHandle, "GetTaskTagAssignments", APIType.GetMany, kinds(ek.TaskTagAssignment), nil},
+		{"/api/get_task_tags", getTaskTagsHandle, "GetTaskTags", APIType.GetMany, kinds(ek.TaskTagAssignment, ek.TagAssignment, ek.BucketTagAssignment, ek.Task), nil},
+		{"/api/create_task_tag_assignment", createTaskTagAssignmentHandle, "CreateTaskTagAssignment", APIType.Create, nil, kinds(ek.TaskTagAssignment)},
+		{"/api/destroy_task_tag_assignment_by_id", destroyTaskTagAssignmentByIDHandle, "DestroyTaskTagAssignmentByID", APIType.Destroy, nil, kinds(ek.TaskTagAssignment)},
+		// trash
+		{"/api/get_trash", getTrashHandle, "GetTrash", APIType.GetMany, kinds(ek.Task, ek.Story, ek.Bucket, ek.Comment), nil},
+		{"/api/restore_entity", restoreEntityHandle, "RestoreEntity", APIType.Put, nil, kinds(ek.Task, ek.Story, ek.Bucket, ek.Comment)},
+		// search
+		{"/api/search", searchHandle, "Search", APIType.GetMany, kinds(ek.Task, ek.Story, ek.Bucket, ek.Comment), nil},
+		// bulk
+		{"/api/bulk_update", bulkUpdateHandle, "BulkUpdate", APIType.Put, nil, kinds(ek.Task, ek.Story, ek.TagAssignment, ek.TaskTagAssignment)},
+		// saved_views
+		{"/api/get_saved_views", getSavedViewsHandle, "GetSavedViews", APIType.GetMany, kinds(ek.SavedView), nil},
+		{"/api/create_saved_view", createSavedViewHandle, "CreateSavedView", APIType.Create, nil, kinds(ek.SavedView)},
+		{"/api/put_saved_view", putSavedViewHandle, "PutSavedView", APIType.Put, nil, kinds(ek.SavedView)},
+		{"/api/destroy_saved_view", destroySavedViewHandle, "DestroySavedView", APIType.Destroy, nil, kinds(ek.SavedView)},
+		{"/api/get_view_results", getViewResultsHandle, "GetViewResults", APIType.GetMany, kinds(ek.SavedView, ek.Task, ek.Comment, ek.Story, ek.Sprint, ek.Tag, ek.TagAssignment, ek.BucketTagAssignment, ek.TaskTagAssignment, ek.ChecklistItem), nil},
+		// history
+		{"/api/get_entity_history", getEntityHistoryHandle, "GetEntityHistory", APIType.GetMany, kinds(ek.Task, ek.Story, ek.Comment), nil},
+		// analytics
+		{"/api/get_sprint_flow_metrics", getSprintFlowMetricsHandle, "GetSprintFlowMetrics", APIType.GetMany, kinds(ek.Task, ek.Story, ek.Sprint), nil},
+		{"/api/get_sprint_report", getSprintReportHandle, "GetSprintReport", APIType.Get, kinds(ek.Task, ek.Story, ek.Sprint, ek.StoryRelationship), nil},
 	}
 
 	for _, route := range apiRoutes {
 		middlewares := []utils.Middleware{
 			improvedLogReqMiddleware(log),
-			apiCache.Middleware(route.APIType == APIType.Get || route.APIType == APIType.GetMany),
+			apiCache.Middleware(route.APIType == APIType.Get || route.APIType == APIType.GetMany, route.Reads, route.Writes),
 			eventRecorder.Middleware(env.CallerID, route.APIName, route.APIType, createEntityIDKey, getRequestBytesKey),
 			sessionMiddleware(),
 			putAPILatencyMetricMiddleware(route.APIName, route.APIType),
@@ -19,7 +19,9 @@ import (
 	"github.com/bschlaman/todo-app/eventlog"
 	"github.com/bschlaman/todo-app/metrics"
 	"github.com/bschlaman/todo-app/session"
+	"github.com/bschlaman/todo-app/sprints"
 	"github.com/bschlaman/todo-app/storage"
+	"github.com/bschlaman/todo-app/trash"
 	"github.com/sqids/sqids-go"
 )
 
@@ -37,6 +39,13 @@ const (
 	getRequestBytesKey     CustomContextKey = "getReqKey"
 	cacheTTL               time.Duration    = 2 * time.Second
 	devModeCacheTTL        time.Duration    = 5 * time.Minute
+	cacheJanitorInterval   time.Duration    = time.Minute
+	cacheMaxEntries                         = 1000
+	cacheMaxBytes                           = 64 << 20 // 64 MB
+	trashPurgeInterval     time.Duration    = time.Hour
+	defaultTrashRetention  time.Duration    = 30 * 24 * time.Hour
+	sprintScheduleInterval time.Duration    = time.Hour
+	nextSprintLeadTime     time.Duration    = 3 * 24 * time.Hour
 	rootServerPath         string           = "/sprintboard"
 	uploadsDir                              = "uploads"
 	maxUploadSize                           = 10 << 20 // 10 MB
@@ -75,6 +84,10 @@ var eventRecorder *eventlog.Recorder
 
 var apiCache *cache.Store
 
+var trashPurger *trash.Purger
+
+var sprintScheduler *sprints.Scheduler
+
 // APIType is a kind of enum for classifications of api calls
 var APIType = struct {
 	Util    string
@@ -96,6 +109,42 @@ var APIType = struct {
 	"Upload",
 }
 
+// EntityKind names the kinds of entities an api reads or writes.
+// Used to invalidate cached GETs when a dependency is written
+var EntityKind = struct {
+	Config              string
+	Task                string
+	Comment             string
+	Story               string
+	Sprint              string
+	Tag                 string
+	TagAssignment       string
+	StoryRelationship   string
+	TaskRelationship    string
+	Bucket              string
+	BucketTagAssignment string
+	TaskTagAssignment   string
+	ChecklistItem       string
+	SavedView           string
+	Upload              string
+}{
+	"Config",
+	"Task",
+	"Comment",
+	"Story",
+	"Sprint",
+	"Tag",
+	"TagAssignment",
+	"StoryRelationship",
+	"TaskRelationship",
+	"Bucket",
+	"BucketTagAssignment",
+	"TaskTagAssignment",
+	"ChecklistItem",
+	"SavedView",
+	"Upload",
+}
+
 func init() {
 	// log file
 	file, err := os.OpenFile(path.Join("../..", logPath), os.O_APPEND|os.O_WRONLY, 0644)
@@ -123,7 +172,7 @@ func init() {
 
 	// init globals
 	l := logger.New(mw)
-	sessionManager = session.NewManager(l)
+	sessionManager = session.NewManager(l, sessionDuration)
 	metricsPublisher = metrics.NewPublisher(cwClient, metricNamespace, l)
 	eventRecorder = eventlog.NewRecorder(l)
 	devMode := false
@@ -132,7 +181,18 @@ func init() {
 		cttl = devModeCacheTTL
 		devMode = true
 	}
-	apiCache = cache.NewStore(cttl)
+	// the cache must exist before the background jobs which invalidate it start
+	apiCache = cache.NewStore(cttl, cacheJanitorInterval, cacheMaxEntries, cacheMaxBytes)
+	trashPurger = trash.NewPurger(l, trashPurgeInterval, defaultTrashRetention,
+		func() {
+			// purging also removes what hangs off the purged entities
+			ek := EntityKind
+			apiCache.Invalidate(ek.Task, ek.Story, ek.Bucket, ek.Comment,
+				ek.TagAssignment, ek.BucketTagAssignment, ek.TaskTagAssignment,
+				ek.StoryRelationship, ek.TaskRelationship, ek.ChecklistItem)
+		})
+	sprintScheduler = sprints.NewScheduler(l, sprintScheduleInterval, nextSprintLeadTime, sprintDuration,
+		func() { apiCache.Invalidate(EntityKind.Sprint) })
 	s, _ := sqids.New(sqids.Options{Alphabet: os.Getenv("SQIDS_ALPHABET"), MinLength: 6})
 	env = &Env{l, cfg, cwClient, s3Uploader, os.Getenv("LOGIN_PW"), os.Getenv("CALLER_ID"), s, devMode}
 	log = env.Log
@@ -140,6 +200,9 @@ func init() {
 
 func main() {
 	defer sessionManager.Stop()
+	defer apiCache.Stop()
+	defer trashPurger.Stop()
+	defer sprintScheduler.Stop()
 	defer database.ClosePool()
 	// Make sure we can connect to the database
 	conn, err := database.GetPgxConn()
@@ -6,44 +6,93 @@ import (
 
 	"github.com/bschlaman/b-utils/pkg/logger"
 	"github.com/bschlaman/todo-app/model"
+	"github.com/google/uuid"
 )
 
 // Manager provides thread-safe session management with batched database updates
 type Manager struct {
-	log      *logger.BLogger
-	sessions map[string]model.SessionRecord
-	mutex    sync.RWMutex
+	log             *logger.BLogger
+	sessions        map[string]model.SessionRecord
+	mutex           sync.RWMutex
+	sessionDuration time.Duration
 
 	// For batched updates
 	pendingUpdates map[string]time.Time
 	updateMutex    sync.Mutex
 	updateTicker   *time.Ticker
+	evictTicker    *time.Ticker
 	stopChan       chan struct{}
 }
 
-// NewManager creates a new Manager instance
-func NewManager(log *logger.BLogger) *Manager {
+// NewManager creates a new Manager instance, rehydrating
+// any unexpired sessions from the database
+func NewManager(log *logger.BLogger, sessionDuration time.Duration) *Manager {
 	sm := &Manager{
-		log:            log,
-		sessions:       make(map[string]model.SessionRecord),
-		pendingUpdates: make(map[string]time.Time),
-		stopChan:       make(chan struct{}),
+		log:             log,
+		sessions:        make(map[string]model.SessionRecord),
+		sessionDuration: sessionDuration,
+		pendingUpdates:  make(map[string]time.Time),
+		stopChan:        make(chan struct{}),
 	}
 
+	sm.loadSessions()
+
 	// Start batch update routine (every 30 seconds)
+	// and expired session eviction (every 10 minutes)
 	sm.updateTicker = time.NewTicker(30 * time.Second)
+	sm.evictTicker = time.NewTicker(10 * time.Minute)
 	go sm.batchUpdateRoutine()
 
 	return sm
 }
 
-// GetSession retrieves a session by cookie value (thread-safe)
+// loadSessions populates the in-memory map with unexpired sessions from the database.
+// A failure here is not fatal; sessions will be looked up lazily in GetSession
+func (sm *Manager) loadSessions() {
+	records, err := model.GetUnexpiredSessionRecords(sm.log, time.Now().Add(-sm.sessionDuration))
+	if err != nil {
+		sm.log.Errorf("failed to load sessions from database: %v", err)
+		return
+	}
+
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	for _, s := range records {
+		sm.sessions[s.SessionID] = s
+	}
+	sm.log.Infof("loaded %d unexpired sessions from database", len(records))
+}
+
+// isExpired reports whether a session has outlived sessionDuration
+func (sm *Manager) isExpired(session model.SessionRecord) bool {
+	return time.Now().After(session.SessionCreatedAt.Add(sm.sessionDuration))
+}
+
+// GetSession retrieves a session by cookie value (thread-safe).
+// On a miss, the database is consulted and the in-memory map is populated
 func (sm *Manager) GetSession(cookieValue string) (model.SessionRecord, bool) {
 	sm.mutex.RLock()
-	defer sm.mutex.RUnlock()
-
 	session, exists := sm.sessions[cookieValue]
-	return session, exists
+	sm.mutex.RUnlock()
+	if exists {
+		return session, true
+	}
+
+	// session_id is a uuid column; anything else can't be a valid session
+	if _, err := uuid.Parse(cookieValue); err != nil {
+		return model.SessionRecord{}, false
+	}
+
+	record, err := model.GetSessionRecordBySessionID(sm.log, cookieValue)
+	if err != nil || record == nil || sm.isExpired(*record) {
+		return model.SessionRecord{}, false
+	}
+
+	sm.SetSession(cookieValue, *record)
+	sm.log.Infof("restored session from database: %s", record.SessionID)
+
+	return *record, true
 }
 
 // SetSession stores a session (thread-safe)
@@ -91,6 +140,8 @@ func (sm *Manager) batchUpdateRoutine() {
 		select {
 		case <-sm.updateTicker.C:
 			sm.flushPendingUpdates()
+		case <-sm.evictTicker.C:
+			sm.evictExpiredSessions()
 		case <-sm.stopChan:
 			return
 		}
@@ -118,10 +169,39 @@ func (sm *Manager) flushPendingUpdates() {
 	}()
 }
 
+// evictExpiredSessions removes expired sessions from memory and from the database
+func (sm *Manager) evictExpiredSessions() {
+	sm.mutex.Lock()
+	evicted := 0
+	for cookieValue, s := range sm.sessions {
+		if sm.isExpired(s) {
+			delete(sm.sessions, cookieValue)
+			evicted++
+		}
+	}
+	sm.mutex.Unlock()
+
+	if evicted > 0 {
+		sm.log.Infof("evicted %d expired sessions from memory", evicted)
+	}
+
+	go func() {
+		n, err := model.DestroyExpiredSessionRecords(sm.log, time.Now().Add(-sm.sessionDuration))
+		if err != nil {
+			sm.log.Errorf("failed to destroy expired sessions: %v", err)
+			return
+		}
+		if n > 0 {
+			sm.log.Infof("destroyed %d expired sessions from database", n)
+		}
+	}()
+}
+
 // Stop gracefully shuts down the Manager
 func (sm *Manager) Stop() {
 	close(sm.stopChan)
 	sm.updateTicker.Stop()
+	sm.evictTicker.Stop()
 
 	// Flush any remaining updates before shutting down
 	sm.flushPendingUpdates()
@@ -0,0 +1,66 @@
+package sprints
+
+import (
+	"time"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/bschlaman/todo-app/model"
+)
+
+// Scheduler periodically creates the next sprint
+// once the latest sprint is about to end
+type Scheduler struct {
+	log             *logger.BLogger
+	leadTime        time.Duration
+	defaultDuration time.Duration
+	invalidate      func()
+	ticker          *time.Ticker
+	stopChan        chan struct{}
+}
+
+// NewScheduler creates a Scheduler which runs every interval, and once at startup.
+// The next sprint is created leadTime before the latest sprint ends.
+// defaultDuration is used if the config table has no sprint duration.
+// invalidate is called whenever a sprint is created, to evict cached reads of sprints.
+func NewScheduler(log *logger.BLogger, interval, leadTime, defaultDuration time.Duration, invalidate func()) *Scheduler {
+	s := &Scheduler{
+		log:             log,
+		leadTime:        leadTime,
+		defaultDuration: defaultDuration,
+		invalidate:      invalidate,
+		ticker:          time.NewTicker(interval),
+		stopChan:        make(chan struct{}),
+	}
+	go s.scheduleRoutine()
+	return s
+}
+
+func (s *Scheduler) scheduleRoutine() {
+	s.createNextSprint()
+	for {
+		select {
+		case <-s.ticker.C:
+			s.createNextSprint()
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+func (s *Scheduler) createNextSprint() {
+	sprint, err := model.CreateNextSprintIfDue(s.log, time.Now(), s.leadTime, s.defaultDuration)
+	if err != nil {
+		s.log.Errorf("failed to create next sprint: %v", err)
+		return
+	}
+	if sprint != nil {
+		s.invalidate()
+		s.log.Infof("created sprint %s (%s - %s)", sprint.Title, sprint.StartDate, sprint.EndDate)
+	}
+}
+
+// Stop shuts down the Scheduler
+func (s *Scheduler) Stop() {
+	close(s.stopChan)
+	s.ticker.Stop()
+}
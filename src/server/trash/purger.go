@@ -0,0 +1,78 @@
+package trash
+
+import (
+	"time"
+
+	"github.com/bschlaman/b-utils/pkg/logger"
+	"github.com/bschlaman/todo-app/model"
+)
+
+// retentionConfigKey is the config table key holding the trash retention period
+const retentionConfigKey = "trash_retention_seconds"
+
+// Purger periodically and permanently deletes entities which
+// have been in the trash for longer than the retention period
+type Purger struct {
+	log              *logger.BLogger
+	defaultRetention time.Duration
+	invalidate       func()
+	ticker           *time.Ticker
+	stopChan         chan struct{}
+}
+
+// NewPurger creates a Purger which runs every interval.
+// defaultRetention is used if the config table has no retention period.
+// invalidate is called after a purge which removed anything, to evict cached reads of the trash.
+func NewPurger(log *logger.BLogger, interval, defaultRetention time.Duration, invalidate func()) *Purger {
+	p := &Purger{
+		log:              log,
+		defaultRetention: defaultRetention,
+		invalidate:       invalidate,
+		ticker:           time.NewTicker(interval),
+		stopChan:         make(chan struct{}),
+	}
+	go p.purgeRoutine()
+	return p
+}
+
+func (p *Purger) purgeRoutine() {
+	for {
+		select {
+		case <-p.ticker.C:
+			p.purge()
+		case <-p.stopChan:
+			return
+		}
+	}
+}
+
+// retention reads the retention period from the config table
+func (p *Purger) retention() time.Duration {
+	serverConfig, err := model.GetConfig(p.log)
+	if err != nil {
+		return p.defaultRetention
+	}
+	seconds, ok := serverConfig[retentionConfigKey].(int64)
+	if !ok || seconds <= 0 {
+		return p.defaultRetention
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func (p *Purger) purge() {
+	n, err := model.PurgeDeletedEntities(p.log, time.Now().Add(-p.retention()))
+	if err != nil {
+		p.log.Errorf("failed to purge trash: %v", err)
+		return
+	}
+	if n > 0 {
+		p.invalidate()
+		p.log.Infof("purged %d entities from the trash", n)
+	}
+}
+
+// Stop shuts down the Purger
+func (p *Purger) Stop() {
+	close(p.stopChan)
+	p.ticker.Stop()
+}